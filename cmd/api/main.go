@@ -3,42 +3,66 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/brunomgama/go_rag/internal/common"
 	"github.com/brunomgama/go_rag/internal/config"
 	"github.com/brunomgama/go_rag/internal/embed"
+	"github.com/brunomgama/go_rag/internal/ingest"
 	"github.com/brunomgama/go_rag/internal/llm"
 	"github.com/brunomgama/go_rag/internal/rag"
 	"github.com/brunomgama/go_rag/internal/store"
+	"github.com/brunomgama/go_rag/internal/tokenizer"
 )
 
 type queryRequest struct {
-	Query string `json:query"`
-	TopK  int    `json:top_k"`
+	Query string `json:"query"`
+	TopK  int    `json:"top_k"`
 }
 
 type queryResponse = rag.Answer
 
+const maxUploadBytes = 64 << 20
+
 func main() {
 	cfg := config.Load()
 
-	emb := embed.NewOllama(cfg.OllamaHost, cfg.EmbeddingsModel)
-	llmClient := llm.NewOllama(cfg.OllamaHost, envDefault("LLM_MODEL", "llama3.1:8b"))
-	st := store.NewQdrant(cfg.QdrantURL, cfg.QdrantCollection)
+	emb, err := embed.FromConfig(cfg)
+	if !common.IsNilValue(err) {
+		log.Fatalf("embedder: %v", err)
+	}
+	llmClient, err := llm.FromConfig(cfg)
+	if !common.IsNilValue(err) {
+		log.Fatalf("llm: %v", err)
+	}
+	st, err := store.FromConfig(cfg)
+	if !common.IsNilValue(err) {
+		log.Fatalf("vector store: %v", err)
+	}
+	tok, err := tokenizer.FromConfig(cfg)
+	if !common.IsNilValue(err) {
+		log.Fatalf("tokenizer: %v", err)
+	}
 
 	minScroe := float32(0.15)
 	svc := &rag.Service{
-		Embed:    emb,
-		LLM:      llmClient,
-		Store:    st,
-		TopK:     6,
-		MinScore: &minScroe,
+		Embed:     emb,
+		LLM:       llmClient,
+		Store:     st,
+		TopK:      6,
+		MinScore:  &minScroe,
+		Neighbors: cfg.QueryNeighbors,
 	}
 
+	pipeline := ingest.NewPipeline(cfg, emb, st, tok)
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("POST /query", func(w http.ResponseWriter, r *http.Request) {
 		var req queryRequest
@@ -63,15 +87,200 @@ func main() {
 		json.NewEncoder(w).Encode(ans)
 	})
 
+	mux.HandleFunc("POST /query/stream", func(w http.ResponseWriter, r *http.Request) {
+		var req queryRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if !common.IsNilValue(err) {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 120*time.Second)
+		defer cancel()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		ans, err := svc.QueryStream(ctx, req.Query, req.TopK, func(token string) error {
+			if err := writeEvent(w, "token", map[string]string{"token": token}); err != nil {
+				return err
+			}
+			flusher.Flush()
+			return nil
+		})
+		if !common.IsNilValue(err) {
+			log.Println("query stream error:", err)
+			writeEvent(w, "error", map[string]string{"error": "internal error"})
+			flusher.Flush()
+			return
+		}
+
+		writeEvent(w, "citations", ans.Citations)
+		writeEvent(w, "done", map[string]int64{"latency_ms": ans.LatencyMS})
+		flusher.Flush()
+	})
+
+	mux.HandleFunc("POST /upload", func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes)
+
+		file, header, err := r.FormFile("file")
+		if !common.IsNilValue(err) {
+			http.Error(w, "bad request: expected multipart field \"file\"", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		name := filepath.Base(header.Filename)
+		if name == "." || name == string(filepath.Separator) {
+			http.Error(w, "bad request: missing file name", http.StatusBadRequest)
+			return
+		}
+
+		// docs.ParseFile picks the parser by extension and uses the base name
+		// as doc_id, so the upload keeps its original name inside a temp dir.
+		dir, err := os.MkdirTemp("", "rag-upload-*")
+		if !common.IsNilValue(err) {
+			log.Println("upload error:", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, name)
+		if err := saveUpload(path, file); err != nil {
+			log.Println("upload error:", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 300*time.Second)
+		defer cancel()
+
+		res, err := pipeline.IngestFile(ctx, path)
+		var parseErr *ingest.ParseError
+		if errors.As(err, &parseErr) {
+			http.Error(w, "could not parse file", http.StatusUnprocessableEntity)
+			return
+		}
+		if !common.IsNilValue(err) {
+			log.Println("upload error:", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("Upserted %d vectors for %s", res.Vectors, res.DocID)
+
+		// an earlier upload of the same file may have left more chunks
+		n, err := store.DeleteStale(ctx, st, res.DocID, res.PointKeys)
+		if !common.IsNilValue(err) {
+			log.Println("upload error:", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		if n > 0 {
+			log.Printf("Deleted %d stale vectors for %s", n, res.DocID)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+	})
+
+	mux.HandleFunc("GET /documents", func(w http.ResponseWriter, r *http.Request) {
+		list, err := store.ListDocuments(r.Context(), st)
+		if !common.IsNilValue(err) {
+			log.Println("list documents error:", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"documents": list})
+	})
+
+	// doc ids may hold slashes, e.g. archive members like
+	// "bundle.zip!/path/inner.md", so the id takes the rest of the path
+	mux.HandleFunc("GET /documents/{doc_id...}", func(w http.ResponseWriter, r *http.Request) {
+		docID := r.PathValue("doc_id")
+		chunks, err := store.DocumentChunks(r.Context(), st, docID)
+		if !common.IsNilValue(err) {
+			log.Println("document chunks error:", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		if len(chunks) == 0 {
+			http.Error(w, "document not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"doc_id": docID, "chunks": chunks})
+	})
+
+	mux.HandleFunc("DELETE /documents/{doc_id...}", func(w http.ResponseWriter, r *http.Request) {
+		docID := r.PathValue("doc_id")
+		chunks, err := store.DocumentChunks(r.Context(), st, docID)
+		if !common.IsNilValue(err) {
+			log.Println("delete document error:", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		if len(chunks) == 0 {
+			http.Error(w, "document not found", http.StatusNotFound)
+			return
+		}
+
+		if err := store.DeleteDocument(r.Context(), st, docID); err != nil {
+			log.Println("delete document error:", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("Deleted %d vectors for %s", len(chunks), docID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"doc_id": docID, "deleted": len(chunks)})
+	})
+
 	port := envDefault("PORT", "8080")
 	log.Printf("API listening on :%s", port)
 	log.Fatal(http.ListenAndServe(":"+port, withCORS(mux)))
 }
 
+func saveUpload(path string, src io.Reader) error {
+	dst, err := os.Create(path)
+	if !common.IsNilValue(err) {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+func writeEvent(w io.Writer, event string, data any) error {
+	b, err := json.Marshal(data)
+	if !common.IsNilValue(err) {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
+
 func withCORS(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
@@ -4,171 +4,189 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/brunomgama/go_rag/internal/config"
 	"github.com/brunomgama/go_rag/internal/docs"
 	"github.com/brunomgama/go_rag/internal/embed"
+	"github.com/brunomgama/go_rag/internal/ingest"
 	"github.com/brunomgama/go_rag/internal/store"
+	"github.com/brunomgama/go_rag/internal/tokenizer"
 	"github.com/joho/godotenv"
 )
 
-type metrics struct {
-	docs         int
-	chunks       int
-	vectors      int
-	parseChunkMs time.Duration
-	embedMs      time.Duration
-	upsertMs     time.Duration
-	approxTokens int
-}
+const usage = `Usage: ingest <command> [flags]
+
+Commands:
+  run      embed new and modified files under --root and purge removed ones (default)
+  status   compare files under --root against the ingest manifest
+  purge    delete documents whose files are gone, or everything with --all
+
+Run "ingest <command> -h" for the flags of a command.
+`
+
+// errUsage marks bad invocations, which exit with status 2 like the flag package.
+var errUsage = errors.New("usage")
 
 func main() {
 	_ = godotenv.Load()
-	cfg := config.Load()
+	os.Exit(run(os.Args[1:], os.Stdout))
+}
 
-	start := time.Now()
-	ctx := context.Background()
-	m := metrics{}
+func run(args []string, out io.Writer) int {
+	cmd := "run"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
 
-	// track duplicates within a run
-	seen := make(map[string]bool)
+	var err error
+	switch cmd {
+	case "run":
+		err = runCmd(args, out)
+	case "status":
+		err = statusCmd(args, out)
+	case "purge":
+		err = purgeCmd(args, out)
+	case "help":
+		fmt.Fprint(out, usage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usage)
+		return 2
+	}
 
-	// collect files
-	var paths []string
-	root := "data"
-	_ = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info == nil || info.IsDir() {
-			return nil
-		}
-		paths = append(paths, path)
-		return nil
-	})
-	if len(paths) == 0 {
-		log.Printf("No files found in %s", root)
-		return
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	default:
+		log.Print(err)
+		return 1
 	}
+}
 
-	// clients
-	emb := embed.NewOllama(cfg.OllamaHost, cfg.EmbeddingsModel)
-	qd := store.NewQdrant(cfg.QdrantURL, cfg.QdrantCollection)
-
-	var dim int
-
-	for _, p := range paths {
-		// dedup by checksum
-		sum, err := fileSHA256(p)
-		if err == nil {
-			if seen[sum] {
-				log.Printf("Skipping duplicate content: %s", p)
-				continue
-			}
-			seen[sum] = true
-		}
+// options are the flags shared by every command. Zero values keep what
+// config.Load read from the environment.
+type options struct {
+	root       string
+	collection string
+	dryRun     bool
+}
 
-		// parse + chunk
-		t0 := time.Now()
-		doc, err := docs.ParseFile(p)
-		if err != nil {
-			log.Printf("parse error %s: %v", p, err)
-			continue
-		}
-		chunks := docs.ChunkByWord(doc, cfg.ChunkTarget, cfg.ChunkOverlap)
-		m.parseChunkMs += time.Since(t0)
-		m.docs++
-		m.chunks += len(chunks)
-		for _, c := range chunks {
-			m.approxTokens += countWords(c.Text)
-		}
-		if len(chunks) == 0 {
-			continue
-		}
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.root, "root", "data", "directory to ingest")
+	fs.StringVar(&o.collection, "collection", "", "collection to write to (default $QDRANT_COLLECTION)")
+}
 
-		// embed + upsert
-		batch := 64
-		var points []store.Point
-		vectorsThisDoc := 0
-
-		for i := 0; i < len(chunks); i += batch {
-			j := i + batch
-			if j > len(chunks) {
-				j = len(chunks)
-			}
-
-			texts := make([]string, j-i)
-			for k := i; k < j; k++ {
-				texts[k-i] = chunks[k].Text
-			}
-
-			t1 := time.Now()
-			vecs, err := emb.Embed(ctx, texts)
-			m.embedMs += time.Since(t1)
-			if err != nil {
-				log.Fatalf("embed error: %v", err)
-			}
-
-			if dim == 0 && len(vecs) > 0 {
-				dim = len(vecs[0])
-				if err := qd.EnsureCollection(ctx, dim); err != nil {
-					log.Fatalf("ensure collection: %v", err)
-				}
-			}
-
-			for k := range vecs {
-				c := chunks[i+k]
-				points = append(points, store.Point{
-					ID:     fmt.Sprintf("%s::%s", c.DocID, c.ChunkID),
-					Vector: vecs[k],
-					Payload: map[string]any{
-						"doc_id":   c.DocID,
-						"page":     c.Page,
-						"chunk_id": c.ChunkID,
-						"text":     c.Text,
-					},
-				})
-			}
-			vectorsThisDoc += len(vecs)
-			m.vectors += len(vecs)
-		}
+func (o *options) registerDryRun(fs *flag.FlagSet) {
+	fs.BoolVar(&o.dryRun, "dry-run", false, "report what would change without touching the store")
+}
 
-		if len(points) > 0 {
-			t2 := time.Now()
-			if err := qd.Upsert(ctx, points); err != nil {
-				log.Fatalf("upsert error: %v", err)
-			}
-			m.upsertMs += time.Since(t2)
-			log.Printf("Upserted %d vectors for %s", vectorsThisDoc, doc.ID)
+func (o *options) apply(cfg *config.Config) {
+	if o.collection != "" {
+		cfg.QdrantCollection = o.collection
+	}
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	fs.SetOutput(os.Stderr)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
 		}
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments: %s\n", strings.Join(fs.Args(), " "))
+		return errUsage
 	}
+	return nil
+}
 
-	// summary
-	elapsed := time.Since(start)
-	chunksPerSec := float64(m.chunks) / elapsed.Seconds()
-	vectorsPerSec := float64(m.vectors) / elapsed.Seconds()
-
-	fmt.Printf(`
-	✅ Ingest complete
-		Docs:    %d
-		Chunks:  %d
-		Vectors: %d
-		~Tokens: %d
-
-	⏰ Timing:
-		Total:        %s
-		Parse+Chunk:  %s
-		Embed:        %s
-		Upsert:       %s
-
-	🔄 Throughput:
-		Chunks/sec:   %.2f
-		Vectors/sec:  %.2f
-`[1:],m.docs, m.chunks, m.vectors, m.approxTokens,elapsed, m.parseChunkMs, m.embedMs, m.upsertMs,chunksPerSec, vectorsPerSec,)
+func newPipeline(cfg config.Config, root string) (*ingest.Pipeline, store.VectorStore, error) {
+	emb, err := embed.FromConfig(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("embedder: %w", err)
+	}
+	qd, err := store.FromConfig(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("vector store: %w", err)
+	}
+	tok, err := tokenizer.FromConfig(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("tokenizer: %w", err)
+	}
+
+	pipeline := ingest.NewPipeline(cfg, emb, qd, tok)
+	pipeline.Root = root
+	return pipeline, qd, nil
+}
+
+// manifestEntry describes path as the current settings would index it, for
+// comparison with the manifest.
+func manifestEntry(cfg config.Config, root, path, sum string) ingest.ManifestEntry {
+	// the semantic options only shape chunks under the semantic strategy
+	var semantic docs.SemanticOptions
+	if cfg.ChunkStrategy == "semantic" {
+		semantic.Percentile, semantic.MinTokens = cfg.SemanticPercentile, cfg.SemanticMinTokens
+	}
+	return ingest.ManifestEntry{
+		Path:               path,
+		DocID:              ingest.DocID(root, path),
+		Checksum:           sum,
+		Collection:         cfg.QdrantCollection,
+		ChunkTarget:        cfg.ChunkTarget,
+		ChunkOverlap:       cfg.ChunkOverlap,
+		ChunkStrategy:      cfg.ChunkStrategy,
+		SemanticPercentile: semantic.Percentile,
+		SemanticMinTokens:  semantic.MinTokens,
+		ParentTokens:       cfg.ParentTokens,
+		Tokenizer:          cfg.TokenizerPath,
+		RecordsPerChunk:    cfg.RecordsPerChunk,
+		RecordTemplate:     cfg.RecordTemplate,
+		RecordFields:       cfg.RecordFields,
+		PDFMaxPages:        cfg.PDFMaxPages,
+		EmbeddingsModel:    cfg.EmbeddingsModel,
+	}
+}
+
+// indexedInStore reports whether the chunks recorded for e are still in the
+// store. Files that produced no chunks have nothing to look for.
+func indexedInStore(ctx context.Context, vs store.VectorStore, e ingest.ManifestEntry) (bool, error) {
+	if e.Chunks == 0 {
+		return true, nil
+	}
+	ok, err := store.HasDocument(ctx, vs, e.DocID)
+	if err != nil {
+		return false, fmt.Errorf("look up %s in the store: %w", e.DocID, err)
+	}
+	return ok, nil
+}
 
+func collectFiles(root string) ([]string, error) {
+	if _, err := os.Stat(root); err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	_ = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info == nil || info.IsDir() {
+			return nil
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	return paths, nil
 }
 
 func fileSHA256(path string) (string, error) {
@@ -180,4 +198,7 @@ func fileSHA256(path string) (string, error) {
 	return hex.EncodeToString(sum[:]), nil
 }
 
-func countWords(s string) int { return len(strings.Fields(s)) }
+func underRoot(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
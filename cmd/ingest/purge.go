@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/brunomgama/go_rag/internal/config"
+	"github.com/brunomgama/go_rag/internal/ingest"
+	"github.com/brunomgama/go_rag/internal/store"
+)
+
+func purgeCmd(args []string, out io.Writer) error {
+	cfg := config.Load()
+
+	var opts options
+	fs := flag.NewFlagSet("purge", flag.ContinueOnError)
+	opts.register(fs)
+	opts.registerDryRun(fs)
+	all := fs.Bool("all", false, "delete every document recorded for the collection, not only those whose files are gone")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	opts.apply(&cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	manifest, err := ingest.LoadManifest(cfg.ManifestPath)
+	if err != nil {
+		return fmt.Errorf("manifest: %w", err)
+	}
+
+	var qd store.VectorStore
+	if !opts.dryRun {
+		qd, err = store.FromConfig(cfg)
+		if err != nil {
+			return fmt.Errorf("vector store: %w", err)
+		}
+	}
+
+	if !*all {
+		paths, err := collectFiles(opts.root)
+		if err != nil {
+			return err
+		}
+		walked := make(map[string]bool, len(paths))
+		for _, p := range paths {
+			walked[p] = true
+		}
+
+		purged, err := purgeMissing(ctx, qd, manifest, cfg.QdrantCollection, opts.root, walked, opts.dryRun, out)
+		if err != nil {
+			return err
+		}
+		reportPurged(out, purged, opts.dryRun)
+		return nil
+	}
+
+	purged := 0
+	for _, p := range manifest.Paths(cfg.QdrantCollection) {
+		prev, _ := manifest.Get(cfg.QdrantCollection, p)
+		purged++
+		if opts.dryRun {
+			fmt.Fprintf(out, "would purge %s\n", prev.DocID)
+			continue
+		}
+		if err := store.DeleteDocument(ctx, qd, prev.DocID); err != nil {
+			return fmt.Errorf("delete %s: %w", prev.DocID, err)
+		}
+		manifest.Remove(cfg.QdrantCollection, p)
+		log.Printf("Purged %s", prev.DocID)
+		if err := manifest.Save(); err != nil {
+			return fmt.Errorf("save manifest: %w", err)
+		}
+	}
+
+	reportPurged(out, purged, opts.dryRun)
+	return nil
+}
+
+func reportPurged(out io.Writer, n int, dryRun bool) {
+	if dryRun {
+		fmt.Fprintf(out, "%d document(s) would be purged\n", n)
+		return
+	}
+	fmt.Fprintf(out, "%d document(s) purged\n", n)
+}
+
+// purgeMissing deletes the documents of manifest entries under root whose
+// files were not walked in this run, and returns how many it removed.
+func purgeMissing(ctx context.Context, qd store.VectorStore, manifest *ingest.Manifest, collection, root string, walked map[string]bool, dryRun bool, out io.Writer) (int, error) {
+	purged := 0
+	for _, p := range manifest.Paths(collection) {
+		if walked[p] || !underRoot(root, p) {
+			continue
+		}
+		prev, _ := manifest.Get(collection, p)
+		purged++
+		if dryRun {
+			fmt.Fprintf(out, "would purge %s\n", prev.DocID)
+			continue
+		}
+		if err := store.DeleteDocument(ctx, qd, prev.DocID); err != nil {
+			return purged - 1, fmt.Errorf("delete %s: %w", prev.DocID, err)
+		}
+		manifest.Remove(collection, p)
+		log.Printf("Purged %s (file removed)", prev.DocID)
+	}
+
+	if dryRun {
+		return purged, nil
+	}
+	if err := manifest.Save(); err != nil {
+		return purged, fmt.Errorf("save manifest: %w", err)
+	}
+	return purged, nil
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/brunomgama/go_rag/internal/config"
+	"github.com/brunomgama/go_rag/internal/ingest"
+	"github.com/brunomgama/go_rag/internal/store"
+)
+
+type metrics struct {
+	docs         int
+	chunks       int
+	vectors      int
+	parseChunkMs time.Duration
+	embedMs      time.Duration
+	upsertMs     time.Duration
+	tokens       int
+	emptyPages   int
+	warnings     int
+	unchanged    int
+	reindexed    int
+	purged       int
+	failed       int
+}
+
+func runCmd(args []string, out io.Writer) error {
+	cfg := config.Load()
+
+	var opts options
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	opts.register(fs)
+	opts.registerDryRun(fs)
+	batch := fs.Int("batch", cfg.IngestBatchSize, "chunks per embedding request and upsert")
+	workers := fs.Int("workers", 0, "workers per pipeline stage (default from INGEST_*_WORKERS)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *batch <= 0 || *workers < 0 {
+		fmt.Fprintln(os.Stderr, "--batch must be positive and --workers non-negative")
+		return errUsage
+	}
+	opts.apply(&cfg)
+	cfg.IngestBatchSize = *batch
+	if *workers > 0 {
+		cfg.ParseWorkers, cfg.ChunkWorkers, cfg.EmbedWorkers, cfg.UpsertWorkers = *workers, *workers, *workers, *workers
+	}
+
+	start := time.Now()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	m := metrics{}
+
+	// track duplicates within a run
+	seen := make(map[string]bool)
+
+	paths, err := collectFiles(opts.root)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		log.Printf("No files found in %s", opts.root)
+	}
+
+	manifest, err := ingest.LoadManifest(cfg.ManifestPath)
+	if err != nil {
+		return fmt.Errorf("manifest: %w", err)
+	}
+
+	var (
+		pipeline *ingest.Pipeline
+		qd       store.VectorStore
+	)
+	if opts.dryRun {
+		qd, err = store.FromConfig(cfg)
+		if err != nil {
+			return fmt.Errorf("vector store: %w", err)
+		}
+	} else {
+		pipeline, qd, err = newPipeline(cfg, opts.root)
+		if err != nil {
+			return err
+		}
+	}
+
+	// decide what needs indexing before the pipeline starts, so only this
+	// goroutine ever touches the manifest
+	walked := make(map[string]bool, len(paths))
+	pending := make(map[string]ingest.ManifestEntry)
+	replaced := make(map[string]ingest.ManifestEntry)
+	var queue []string
+	fresh := 0
+	for _, p := range paths {
+		walked[p] = true
+
+		// dedup by checksum
+		sum, err := fileSHA256(p)
+		if err == nil {
+			if seen[sum] {
+				log.Printf("Skipping duplicate content: %s", p)
+				continue
+			}
+			seen[sum] = true
+		}
+
+		entry := manifestEntry(cfg, opts.root, p, sum)
+		prev, known := manifest.Get(cfg.QdrantCollection, p)
+		if known && prev.Same(entry) {
+			indexed, err := indexedInStore(ctx, qd, prev)
+			if err != nil {
+				return err
+			}
+			if indexed {
+				m.unchanged++
+				continue
+			}
+			// deleted from the store behind the manifest, e.g. through the API
+			log.Printf("No chunks of %s in the store, ingesting %s again", prev.DocID, p)
+			known = false
+		}
+		if known {
+			m.reindexed++
+			if opts.dryRun {
+				fmt.Fprintf(out, "would reindex %s\n", p)
+				continue
+			}
+			// the old chunks stay until the new ones are upserted, so a failed
+			// or interrupted run leaves the previous revision searchable
+			replaced[p] = prev
+		} else if opts.dryRun {
+			fresh++
+			fmt.Fprintf(out, "would ingest %s\n", p)
+			continue
+		}
+		pending[p] = entry
+		queue = append(queue, p)
+	}
+
+	if opts.dryRun {
+		purged, err := purgeMissing(ctx, nil, manifest, cfg.QdrantCollection, opts.root, walked, true, out)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "dry run: %d unchanged, %d to reindex, %d new, %d to purge\n",
+			m.unchanged, m.reindexed, fresh, purged)
+		return nil
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	in := make(chan string)
+	go func() {
+		defer close(in)
+		for _, p := range queue {
+			select {
+			case in <- p:
+			case <-runCtx.Done():
+				return
+			}
+		}
+	}()
+
+	var failed error
+	for res := range pipeline.Run(runCtx, in) {
+		var parseErr *ingest.ParseError
+		if errors.As(res.Err, &parseErr) {
+			log.Print(parseErr)
+			m.failed++
+			continue
+		}
+		m.parseChunkMs += res.ParseChunk
+		m.embedMs += res.Embed
+		m.upsertMs += res.Upsert
+		m.docs++
+		m.chunks += res.Chunks
+		m.tokens += res.Tokens
+		m.emptyPages += res.EmptyPages
+		m.warnings += len(res.Warnings)
+		for _, w := range res.Warnings {
+			log.Printf("Warning %s page %d (%s): %s", w.DocID, w.Page, w.Kind, w.Message)
+		}
+		m.vectors += res.Vectors
+		if res.Err != nil {
+			log.Print(res.Err)
+			m.failed++
+			if failed == nil {
+				failed = res.Err
+				cancel()
+			}
+			continue
+		}
+		if res.Vectors > 0 {
+			log.Printf("Upserted %d vectors for %s", res.Vectors, res.DocID)
+		}
+		if prev, ok := replaced[res.Path]; ok {
+			// a shorter revision, or one under a new doc id, leaves chunks
+			// the upsert did not overwrite
+			n, err := store.DeleteStale(ctx, qd, prev.DocID, res.PointKeys)
+			if err != nil {
+				return fmt.Errorf("delete stale chunks of %s: %w", prev.DocID, err)
+			}
+			if n > 0 {
+				log.Printf("Deleted %d stale vectors for %s", n, prev.DocID)
+			}
+		}
+
+		entry := pending[res.Path]
+		entry.DocID = res.DocID
+		entry.Chunks = res.Chunks
+		entry.IngestedAt = time.Now().UTC()
+		manifest.Put(entry)
+		if err := manifest.Save(); err != nil {
+			return fmt.Errorf("save manifest: %w", err)
+		}
+	}
+	if failed == nil && ctx.Err() != nil {
+		failed = ctx.Err()
+	}
+	if failed != nil {
+		return fmt.Errorf("ingest aborted: %w", failed)
+	}
+
+	m.purged, err = purgeMissing(ctx, qd, manifest, cfg.QdrantCollection, opts.root, walked, false, out)
+	if err != nil {
+		return err
+	}
+
+	// summary
+	elapsed := time.Since(start)
+	chunksPerSec := float64(m.chunks) / elapsed.Seconds()
+	vectorsPerSec := float64(m.vectors) / elapsed.Seconds()
+
+	fmt.Fprintf(out, `
+	✅ Ingest complete
+		Docs:    %d
+		Chunks:  %d
+		Vectors: %d
+		Tokens:  %d
+		Failed:  %d
+
+	⚠️  Pages:
+		Empty:     %d
+		Warnings:  %d
+
+	📒 Manifest:
+		Unchanged:  %d
+		Reindexed:  %d
+		Purged:     %d
+
+	⏰ Timing (summed across workers):
+		Total:        %s
+		Parse+Chunk:  %s
+		Embed:        %s
+		Upsert:       %s
+
+	🔄 Throughput:
+		Chunks/sec:   %.2f
+		Vectors/sec:  %.2f
+`[1:], m.docs, m.chunks, m.vectors, m.tokens, m.failed, m.emptyPages, m.warnings, m.unchanged, m.reindexed, m.purged, elapsed, m.parseChunkMs, m.embedMs, m.upsertMs, chunksPerSec, vectorsPerSec)
+
+	if m.failed > 0 {
+		return fmt.Errorf("%d file(s) could not be parsed", m.failed)
+	}
+	return nil
+}
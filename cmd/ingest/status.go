@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/brunomgama/go_rag/internal/config"
+	"github.com/brunomgama/go_rag/internal/ingest"
+	"github.com/brunomgama/go_rag/internal/store"
+)
+
+func statusCmd(args []string, out io.Writer) error {
+	cfg := config.Load()
+
+	var opts options
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	opts.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	opts.apply(&cfg)
+
+	manifest, err := ingest.LoadManifest(cfg.ManifestPath)
+	if err != nil {
+		return fmt.Errorf("manifest: %w", err)
+	}
+	paths, err := collectFiles(opts.root)
+	if err != nil {
+		return err
+	}
+	qd, err := store.FromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("vector store: %w", err)
+	}
+	ctx := context.Background()
+
+	counts := make(map[string]int)
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATE\tPATH\tDOC_ID\tCHUNKS\tINGESTED")
+
+	walked := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		walked[p] = true
+
+		prev, known := manifest.Get(cfg.QdrantCollection, p)
+		if !known {
+			counts["new"]++
+			fmt.Fprintf(tw, "new\t%s\t-\t-\t-\n", p)
+			continue
+		}
+
+		sum, _ := fileSHA256(p)
+		entry := manifestEntry(cfg, opts.root, p, sum)
+		state := "indexed"
+		switch {
+		case prev.Checksum != sum:
+			state = "modified"
+		case !prev.Same(entry):
+			state = "stale"
+		default:
+			indexed, err := indexedInStore(ctx, qd, prev)
+			if err != nil {
+				return err
+			}
+			if !indexed {
+				state = "deleted"
+			}
+		}
+		counts[state]++
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", state, p, prev.DocID, prev.Chunks, prev.IngestedAt.Format(time.RFC3339))
+	}
+
+	for _, p := range manifest.Paths(cfg.QdrantCollection) {
+		if walked[p] || !underRoot(opts.root, p) {
+			continue
+		}
+		prev, _ := manifest.Get(cfg.QdrantCollection, p)
+		counts["missing"]++
+		fmt.Fprintf(tw, "missing\t%s\t%s\t%d\t%s\n", p, prev.DocID, prev.Chunks, prev.IngestedAt.Format(time.RFC3339))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "\ncollection %s: %d indexed, %d modified, %d stale, %d deleted, %d new, %d missing\n",
+		cfg.QdrantCollection, counts["indexed"], counts["modified"], counts["stale"], counts["deleted"], counts["new"], counts["missing"])
+	return nil
+}
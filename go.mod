@@ -6,6 +6,5 @@ require (
 	github.com/go-resty/resty/v2 v2.16.5
 	github.com/joho/godotenv v1.5.1
 	github.com/ledongthuc/pdf v0.0.0-20250511090121-5959a4027728
+	golang.org/x/net v0.33.0
 )
-
-require golang.org/x/net v0.33.0 // indirect
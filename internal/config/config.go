@@ -3,33 +3,84 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/brunomgama/go_rag/internal/common"
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	EmbeddingsModel  string
-	OllamaHost       string
-	QdrantURL        string
-	QdrantCollection string
-	ChunkTarget      int
-	ChunkOverlap     int
-	llm_Model        string
-	llm_Port         int
+	EmbeddingsModel    string
+	EmbeddingsProvider string
+	OpenAIBaseURL      string
+	OpenAIAPIKey       string
+	OllamaHost         string
+	QdrantURL          string
+	QdrantCollection   string
+	VectorStore        string
+	MemoryStorePath    string
+	ManifestPath       string
+	IngestBatchSize    int
+	ParseWorkers       int
+	ChunkWorkers       int
+	EmbedWorkers       int
+	UpsertWorkers      int
+	RecordsPerChunk    int
+	RecordTemplate     string
+	RecordFields       []string
+	ArchiveMaxMembers  int
+	ArchiveMaxBytes    int64
+	PDFMaxPages        int
+	ChunkTarget        int
+	ChunkOverlap       int
+	ChunkStrategy      string
+	SemanticPercentile float64
+	SemanticMinTokens  int
+	ParentTokens       int
+	TokenizerPath      string
+	QueryNeighbors     int
+	LLMProvider        string
+	LLMModel           string
+	LLMTemperature     float64
+	llm_Port           int
 }
 
 func Load() Config {
 	_ = godotenv.Load()
 	return Config{
-		EmbeddingsModel:  envDefault("EMBEDDINGS_MODEL", "nomic-embed-text"),
-		OllamaHost:       envDefault("OLLAMA_HOST", "http://localhost:11434"),
-		QdrantURL:        envDefault("QDRANT_URL", "http://localhost:6333"),
-		QdrantCollection: envDefault("QDRANT_COLLECTION", "docs"),
-		ChunkTarget:      mustInt(os.Getenv("CHUNK_TOKEN_TARGET"), 800),
-		ChunkOverlap:     mustInt(os.Getenv("CHUNK_OVERLAP"), 120),
-		llm_Model:        envDefault("LLM_MODEL", "llama3.1:8b"),
-		llm_Port:         mustInt(os.Getenv("LLM_PORT"), 8080),
+		EmbeddingsModel:    envDefault("EMBEDDINGS_MODEL", "nomic-embed-text"),
+		EmbeddingsProvider: envDefault("EMBEDDINGS_PROVIDER", "ollama"),
+		OpenAIBaseURL:      envDefault("OPENAI_BASE_URL", "https://api.openai.com/v1"),
+		OpenAIAPIKey:       os.Getenv("OPENAI_API_KEY"),
+		OllamaHost:         envDefault("OLLAMA_HOST", "http://localhost:11434"),
+		QdrantURL:          envDefault("QDRANT_URL", "http://localhost:6333"),
+		QdrantCollection:   envDefault("QDRANT_COLLECTION", "docs"),
+		VectorStore:        envDefault("VECTOR_STORE", "qdrant"),
+		MemoryStorePath:    envDefault("MEMORY_STORE_PATH", "memory_data/points.json"),
+		ManifestPath:       envDefault("INGEST_MANIFEST", ".ingest/manifest.json"),
+		IngestBatchSize:    mustInt(os.Getenv("INGEST_BATCH_SIZE"), 64),
+		ParseWorkers:       mustInt(os.Getenv("INGEST_PARSE_WORKERS"), 2),
+		ChunkWorkers:       mustInt(os.Getenv("INGEST_CHUNK_WORKERS"), 2),
+		EmbedWorkers:       mustInt(os.Getenv("INGEST_EMBED_WORKERS"), 2),
+		UpsertWorkers:      mustInt(os.Getenv("INGEST_UPSERT_WORKERS"), 1),
+		RecordsPerChunk:    mustInt(os.Getenv("RECORDS_PER_CHUNK"), 1),
+		RecordTemplate:     os.Getenv("RECORD_TEMPLATE"),
+		RecordFields:       splitList(os.Getenv("RECORD_FIELDS")),
+		ArchiveMaxMembers:  mustInt(os.Getenv("ARCHIVE_MAX_MEMBERS"), 1000),
+		ArchiveMaxBytes:    mustInt64(os.Getenv("ARCHIVE_MAX_BYTES"), 512<<20),
+		PDFMaxPages:        mustInt(os.Getenv("PDF_MAX_PAGES"), 0),
+		ChunkTarget:        mustInt(os.Getenv("CHUNK_TOKEN_TARGET"), 800),
+		ChunkOverlap:       mustInt(os.Getenv("CHUNK_OVERLAP"), 120),
+		ChunkStrategy:      envDefault("CHUNK_STRATEGY", "tokens"),
+		SemanticPercentile: mustFloat(os.Getenv("SEMANTIC_BREAK_PERCENTILE"), 10),
+		SemanticMinTokens:  mustInt(os.Getenv("SEMANTIC_MIN_TOKENS"), 100),
+		ParentTokens:       mustInt(os.Getenv("CHUNK_PARENT_TOKENS"), 0),
+		TokenizerPath:      os.Getenv("TOKENIZER_PATH"),
+		QueryNeighbors:     mustInt(os.Getenv("QUERY_NEIGHBORS"), 0),
+		LLMProvider:        envDefault("LLM_PROVIDER", "ollama"),
+		LLMModel:           envDefault("LLM_MODEL", "llama3.1:8b"),
+		LLMTemperature:     mustFloat(os.Getenv("LLM_TEMPERATURE"), 0.2),
+		llm_Port:           mustInt(os.Getenv("LLM_PORT"), 8080),
 	}
 }
 
@@ -50,3 +101,35 @@ func mustInt(s string, def int) int {
 	}
 	return v
 }
+
+func mustInt64(s string, def int64) int64 {
+	if s == "" {
+		return def
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if !common.IsNilValue(err) {
+		return def
+	}
+	return v
+}
+
+func mustFloat(s string, def float64) float64 {
+	if s == "" {
+		return def
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if !common.IsNilValue(err) {
+		return def
+	}
+	return v
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,193 @@
+package docs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/brunomgama/go_rag/internal/common"
+)
+
+// ArchiveLimits bound what ParseAll extracts from a single archive. Sizes
+// are counted on the decompressed bytes actually read, not on headers.
+type ArchiveLimits struct {
+	MaxMembers    int
+	MaxTotalBytes int64
+}
+
+var errArchiveLimit = errors.New("archive exceeds extraction limits")
+
+func IsArchive(p string) bool {
+	name := strings.ToLower(p)
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseAll parses path like ParseFile, except that archives yield one
+// Document per supported member. Member documents get IDs like
+// "bundle.zip!/path/inner.md" and carry the archive name in Bundle.
+func ParseAll(p string, opts ParseOptions, limits ArchiveLimits) ([]Document, error) {
+	if !IsArchive(p) {
+		doc, err := ParseFile(p, opts)
+		if !common.IsNilValue(err) {
+			return nil, err
+		}
+		return []Document{doc}, nil
+	}
+
+	tmp, err := os.MkdirTemp("", "rag-archive-*")
+	if !common.IsNilValue(err) {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	x := &extractor{archive: p, dir: tmp, opts: opts, limits: limits}
+	if strings.HasSuffix(strings.ToLower(p), ".zip") {
+		err = x.zip()
+	} else {
+		err = x.tar()
+	}
+	if !common.IsNilValue(err) {
+		return nil, fmt.Errorf("archive %s: %w", p, err)
+	}
+	return x.docs, nil
+}
+
+type extractor struct {
+	archive string
+	dir     string
+	opts    ParseOptions
+	limits  ArchiveLimits
+	members int
+	total   int64
+	docs    []Document
+}
+
+func (x *extractor) zip() error {
+	zr, err := zip.OpenReader(x.archive)
+	if !common.IsNilValue(err) {
+		return err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if !common.IsNilValue(err) {
+			return err
+		}
+		err = x.member(f.Name, rc)
+		rc.Close()
+		if !common.IsNilValue(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *extractor) tar() error {
+	f, err := os.Open(x.archive)
+	if !common.IsNilValue(err) {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if name := strings.ToLower(x.archive); strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".tgz") {
+		gz, err := gzip.NewReader(f)
+		if !common.IsNilValue(err) {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if !common.IsNilValue(err) {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := x.member(hdr.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// member extracts one entry under its own base name, so the usual
+// extension based parser applies, then re-labels the parsed document.
+func (x *extractor) member(name string, r io.Reader) error {
+	name = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+	if IsArchive(name) {
+		log.Printf("Skipping nested archive %s!/%s", x.archive, name)
+		return nil
+	}
+
+	x.members++
+	if x.limits.MaxMembers > 0 && x.members > x.limits.MaxMembers {
+		return fmt.Errorf("%w: more than %d members", errArchiveLimit, x.limits.MaxMembers)
+	}
+
+	dir := filepath.Join(x.dir, common.Itoa(x.members))
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		return err
+	}
+	dst := filepath.Join(dir, path.Base(name))
+	out, err := os.Create(dst)
+	if !common.IsNilValue(err) {
+		return err
+	}
+
+	budget := int64(-1)
+	if x.limits.MaxTotalBytes > 0 {
+		budget = x.limits.MaxTotalBytes - x.total
+		r = io.LimitReader(r, budget+1)
+	}
+	n, err := io.Copy(out, r)
+	out.Close()
+	x.total += n
+	if !common.IsNilValue(err) {
+		return err
+	}
+	if budget >= 0 && n > budget {
+		return fmt.Errorf("%w: more than %d bytes", errArchiveLimit, x.limits.MaxTotalBytes)
+	}
+
+	doc, err := ParseFile(dst, x.opts)
+	if !common.IsNilValue(err) {
+		log.Printf("Skipping %s!/%s: %v", x.archive, name, err)
+		return nil
+	}
+	if doc.MIME == "text/plain" && !utf8.ValidString(doc.Content) {
+		// the plain text fallback would index binary members as garbage
+		return nil
+	}
+
+	bundle := filepath.Base(x.archive)
+	doc.ID = bundle + "!/" + name
+	doc.Path = x.archive + "!/" + name
+	doc.Bundle = bundle
+	x.docs = append(x.docs, doc)
+	return nil
+}
@@ -0,0 +1,119 @@
+package docs
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeTarGz(t *testing.T, name string, members [][2]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0o755})
+	for _, m := range members {
+		if err := tw.WriteHeader(&tar.Header{Name: m[0], Mode: 0o644, Size: int64(len(m[1]))}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(m[1]))
+	}
+	tw.Close()
+	gz.Close()
+	f.Close()
+	return path
+}
+
+func docIDs(docs []Document) []string {
+	out := make([]string, len(docs))
+	for i, d := range docs {
+		out[i] = d.ID
+	}
+	return out
+}
+
+func TestParseAllZip(t *testing.T) {
+	path := writeZipFile(t, "bundle.zip", [][2]string{
+		{"readme.txt", "Plain notes."},
+		{"docs/", ""},
+		{"docs/rules.md", "# Rules\n\nRoll the dice."},
+		{"inner.zip", "PK nested archive"},
+		{"more.tar.gz", "nested tarball"},
+		{"../escape.txt", "Cleaned path."},
+		{"logo.bin", "\xff\xfe\x00binary"},
+	})
+	docs, err := ParseAll(path, ParseOptions{}, ArchiveLimits{MaxMembers: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"bundle.zip!/readme.txt", "bundle.zip!/docs/rules.md", "bundle.zip!/escape.txt"}
+	if got := docIDs(docs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("docs = %q, want %q", got, want)
+	}
+	for _, d := range docs {
+		if d.Bundle != "bundle.zip" || d.Path != path+"!/"+strings.TrimPrefix(d.ID, "bundle.zip!/") {
+			t.Errorf("%s: bundle %q, path %q", d.ID, d.Bundle, d.Path)
+		}
+	}
+	if got := sectionPaths(docs[1].Sections); !reflect.DeepEqual(got, []string{"Rules"}) {
+		t.Errorf("markdown member sections = %q", got)
+	}
+}
+
+func TestParseAllMaxMembers(t *testing.T) {
+	// nested archives are skipped before they count as members
+	path := writeZipFile(t, "bundle.zip", [][2]string{
+		{"a.txt", "one"}, {"nested.zip", "x"}, {"b.txt", "two"}, {"c.txt", "three"},
+	})
+	if _, err := ParseAll(path, ParseOptions{}, ArchiveLimits{MaxMembers: 3}); err != nil {
+		t.Errorf("3 members under a limit of 3: %v", err)
+	}
+	_, err := ParseAll(path, ParseOptions{}, ArchiveLimits{MaxMembers: 2})
+	if !errors.Is(err, errArchiveLimit) {
+		t.Errorf("err = %v, want errArchiveLimit", err)
+	}
+}
+
+func TestParseAllMaxTotalBytes(t *testing.T) {
+	members := [][2]string{
+		{"a.txt", strings.Repeat("a", 600)},
+		{"b.txt", strings.Repeat("b", 400)},
+	}
+	for name, path := range map[string]string{
+		"zip":    writeZipFile(t, "bundle.zip", members),
+		"tar.gz": writeTarGz(t, "bundle.tar.gz", members),
+	} {
+		if _, err := ParseAll(path, ParseOptions{}, ArchiveLimits{MaxTotalBytes: 1000}); err != nil {
+			t.Errorf("%s: 1000 bytes under a limit of 1000: %v", name, err)
+		}
+		_, err := ParseAll(path, ParseOptions{}, ArchiveLimits{MaxTotalBytes: 999})
+		if !errors.Is(err, errArchiveLimit) {
+			t.Errorf("%s: err = %v, want errArchiveLimit", name, err)
+		}
+	}
+}
+
+func TestParseAllTarGz(t *testing.T) {
+	path := writeTarGz(t, "bundle.tgz", [][2]string{
+		{"dir/a.txt", "first"},
+		{"dir/nested.tar", "skipped"},
+		{"b.md", "# B\n\nsecond"},
+	})
+	docs, err := ParseAll(path, ParseOptions{}, ArchiveLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"bundle.tgz!/dir/a.txt", "bundle.tgz!/b.md"}
+	if got := docIDs(docs); !reflect.DeepEqual(got, want) {
+		t.Errorf("docs = %q, want %q", got, want)
+	}
+}
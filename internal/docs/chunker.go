@@ -2,8 +2,11 @@ package docs
 
 import (
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/brunomgama/go_rag/internal/common"
+	"github.com/brunomgama/go_rag/internal/tokenizer"
 )
 
 type Chunk struct {
@@ -12,51 +15,231 @@ type Chunk struct {
 	Index   int
 	Text    string
 	ChunkID string
+	Section string
+	Chapter string
+	Title   string
+	Bundle  string
+	Meta    map[string]string
+	Fields  map[string]any
+	Code    *CodeSpan
+	Span    *SourceSpan
+	Parent  *Parent
+
+	// src is the byte range of the chunk in the text handed to the
+	// chunker, translated into Span by Split
+	src span
+}
+
+// SourceSpan locates a chunk in its document in characters (runes), End
+// exclusive. Start and End index Document.Content; for PDFs PageStart and
+// PageEnd index the text of the chunk's page.
+type SourceSpan struct {
+	Start     int
+	End       int
+	PageStart int
+	PageEnd   int
 }
 
+// Splitter chunks one unit of a document: a PDF page, a section or the
+// whole content. Chunks it returns carry their byte range in text so Split
+// can locate them in the document.
+type Splitter func(docID, text string, page int) ([]Chunk, error)
+
+// TokenSplitter cuts chunks of at most target tokens as counted by tok,
+// repeating roughly overlap tokens between neighbours. Chunks break between
+// words; a single word longer than target is cut on its own.
+func TokenSplitter(tok tokenizer.Tokenizer, target, overlap int) Splitter {
+	return func(docID, text string, page int) ([]Chunk, error) {
+		return chunkOne(docID, text, page, tok, target, overlap), nil
+	}
+}
+
+// ChunkByWord chunks doc into runs of at most target words, repeating about
+// overlap words between neighbours.
 func ChunkByWord(doc Document, target, overlap int) []Chunk {
+	chunks, _ := Split(doc, TokenSplitter(tokenizer.Words{}, target, overlap))
+	return chunks
+}
+
+// Split runs split over every PDF page, every section or the whole content
+// of doc, whichever the parser produced, and locates the resulting chunks
+// in doc.Content.
+func Split(doc Document, split Splitter) ([]Chunk, error) {
 	var out []Chunk
+	content := &runeCursor{text: doc.Content}
+	from := 0
 
 	if doc.MIME == "application/pdf" && len(doc.PageText) > 0 {
 		for i, page := range doc.PageText {
-			out = append(out, chunkOne(doc.ID, page, i+1, target, overlap)...)
+			lines, next, ok := mapLines(doc.Content, page, from)
+			from = next
+			local := &runeCursor{text: page}
+			chunks, err := split(doc.ID, page, i+1)
+			if !common.IsNilValue(err) {
+				return nil, err
+			}
+			var heading Section
+			if i < len(doc.PageHeadings) {
+				heading.Heading = doc.PageHeadings[i]
+			}
+			for _, c := range chunks {
+				c.Section, c.Chapter = heading.Path(), heading.Chapter()
+				if ok {
+					c.Span = &SourceSpan{
+						Start:     content.at(lines.at(c.src.start)),
+						End:       content.at(lines.at(c.src.end)),
+						PageStart: local.at(c.src.start),
+						PageEnd:   local.at(c.src.end),
+					}
+				}
+				out = append(out, c)
+			}
+		}
+	} else if len(doc.Sections) > 0 {
+		// sections share one index space so chunk ids stay unique per doc
+		for _, sec := range doc.Sections {
+			lines, next, ok := mapLines(doc.Content, sec.Text, from)
+			from = next
+			chunks, err := split(doc.ID, sec.Text, 0)
+			if !common.IsNilValue(err) {
+				return nil, err
+			}
+			for _, c := range chunks {
+				c.Index = len(out)
+				c.ChunkID = "doc#" + common.Itoa(c.Index)
+				c.Section, c.Chapter = sec.Path(), sec.Chapter()
+				if ok {
+					c.Span = &SourceSpan{Start: content.at(lines.at(c.src.start)), End: content.at(lines.at(c.src.end))}
+				}
+				out = append(out, c)
+			}
 		}
 	} else {
-		out = append(out, chunkOne(doc.ID, doc.Content, 0, target, overlap)...)
+		chunks, err := split(doc.ID, doc.Content, 0)
+		if !common.IsNilValue(err) {
+			return nil, err
+		}
+		for _, c := range chunks {
+			c.Span = &SourceSpan{Start: content.at(c.src.start), End: content.at(c.src.end)}
+			out = append(out, c)
+		}
 	}
-	return out
+	return out, nil
 }
 
-func chunkOne(docID, text string, page, target, overlap int) []Chunk {
-	words := strings.Fields(strings.TrimSpace(text))
+func chunkOne(docID, text string, page int, tok tokenizer.Tokenizer, target, overlap int) []Chunk {
+	target = max(1, target)
+	words, counts := tokenWords(text, wordSpans(text, span{0, len(text)}), tok, target)
 	if len(words) == 0 {
 		return nil
 	}
 
 	var chunks []Chunk
-	step := max(1, target-overlap)
-	for start := 0; start < len(words); start += step {
-		end := min(len(words), start+target)
-		segments := strings.Join(words[start:end], " ")
-		index := len(chunks)
-		chunk_id := ""
-
-		if page > 0 {
-			chunk_id = "page-" + common.Itoa(page) + "#" + common.Itoa(index)
-		} else {
-			chunk_id = "doc#" + common.Itoa(index)
+	for start := 0; start < len(words); {
+		end, tokens := start, 0
+		for end < len(words) && (end == start || tokens+counts[end] <= target) {
+			tokens += counts[end]
+			end++
 		}
 
-		chunks = append(chunks, Chunk{
-			DocID:   docID,
-			Page:    page,
-			Index:   index,
-			Text:    segments,
-			ChunkID: chunk_id,
-		})
+		parts := make([]string, 0, end-start)
+		for _, w := range words[start:end] {
+			parts = append(parts, text[w.start:w.end])
+		}
+		src := span{words[start].start, words[end-1].end}
+		chunks = append(chunks, newChunk(docID, page, len(chunks), strings.Join(parts, " "), src))
 		if end == len(words) {
 			break
 		}
+		start = overlapStart(counts, start, end, target, overlap)
 	}
 	return chunks
 }
+
+func newChunk(docID string, page, index int, text string, src span) Chunk {
+	return Chunk{
+		DocID:   docID,
+		Page:    page,
+		Index:   index,
+		Text:    text,
+		ChunkID: chunkID(page, index),
+		src:     src,
+	}
+}
+
+func chunkID(page, index int) string {
+	if page > 0 {
+		return "page-" + common.Itoa(page) + "#" + common.Itoa(index)
+	}
+	return "doc#" + common.Itoa(index)
+}
+
+// overlapStart steps back from end over up to overlap tokens worth of units,
+// but leaves room for the unit at end so every chunk reaches further than
+// the one before.
+func overlapStart(counts []int, start, end, target, overlap int) int {
+	next, repeated := end, 0
+	for next > start+1 && repeated+counts[next-1] <= overlap && repeated+counts[next-1]+counts[end] <= target {
+		next--
+		repeated += counts[next]
+	}
+	return next
+}
+
+// wordSpans returns the whitespace separated words of text within s.
+func wordSpans(text string, s span) []span {
+	var out []span
+	start := -1
+	for i, r := range text[s.start:s.end] {
+		i += s.start
+		switch {
+		case unicode.IsSpace(r) && start >= 0:
+			out = append(out, span{start, i})
+			start = -1
+		case !unicode.IsSpace(r) && start < 0:
+			start = i
+		}
+	}
+	if start >= 0 {
+		out = append(out, span{start, s.end})
+	}
+	return out
+}
+
+// tokenWords counts the tokens of every word as it appears after a space
+// and cuts words that exceed target into pieces that fit.
+func tokenWords(text string, words []span, tok tokenizer.Tokenizer, target int) ([]span, []int) {
+	outWords := make([]span, 0, len(words))
+	counts := make([]int, 0, len(words))
+	for _, w := range words {
+		n := tok.Count(" " + text[w.start:w.end])
+		if n <= target {
+			outWords = append(outWords, w)
+			counts = append(counts, n)
+			continue
+		}
+		for w.start < w.end {
+			piece := longestPrefix(text[w.start:w.end], tok, target)
+			outWords = append(outWords, span{w.start, w.start + len(piece)})
+			counts = append(counts, tok.Count(" "+piece))
+			w.start += len(piece)
+		}
+	}
+	return outWords, counts
+}
+
+// longestPrefix returns the longest prefix of w, cut at a rune boundary,
+// that stays within target tokens. It always returns at least one rune.
+func longestPrefix(w string, tok tokenizer.Tokenizer, target int) string {
+	lo, hi := 1, utf8.RuneCountInString(w)
+	runes := []rune(w)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if tok.Count(" "+string(runes[:mid])) <= target {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return string(runes[:lo])
+}
@@ -0,0 +1,33 @@
+package docs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChunkByWord(t *testing.T) {
+	words := make([]string, 25)
+	for i := range words {
+		words[i] = "w" + string(rune('a'+i))
+	}
+	doc := Document{ID: "a.txt", MIME: "text/plain", Content: strings.Join(words, " ")}
+
+	chunks := ChunkByWord(doc, 10, 2)
+	if len(chunks) != 3 {
+		t.Fatalf("got %d chunks, want 3", len(chunks))
+	}
+	for i, c := range chunks {
+		if n := len(strings.Fields(c.Text)); n > 10 {
+			t.Errorf("chunk %d has %d words", i, n)
+		}
+		if c.DocID != "a.txt" || c.Index != i || c.Span == nil {
+			t.Errorf("chunk %d = %+v", i, c)
+		}
+	}
+	if !strings.HasPrefix(chunks[1].Text, "wi wj ") {
+		t.Errorf("second chunk %q does not repeat the last 2 words of the first", chunks[1].Text)
+	}
+	if !strings.HasSuffix(chunks[2].Text, "wy") {
+		t.Errorf("last chunk %q does not end the document", chunks[2].Text)
+	}
+}
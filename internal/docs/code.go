@@ -0,0 +1,246 @@
+package docs
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/brunomgama/go_rag/internal/common"
+	"github.com/brunomgama/go_rag/internal/tokenizer"
+)
+
+var codeLanguages = map[string]string{
+	".go": "go", ".py": "python", ".js": "javascript", ".jsx": "javascript",
+	".ts": "typescript", ".tsx": "typescript", ".java": "java", ".kt": "kotlin",
+	".rs": "rust", ".c": "c", ".h": "c", ".cc": "cpp", ".cpp": "cpp", ".hpp": "cpp",
+	".cs": "csharp", ".rb": "ruby", ".php": "php", ".swift": "swift",
+	".scala": "scala", ".sh": "shell", ".sql": "sql", ".proto": "protobuf",
+}
+
+// Symbol is a top-level declaration of a source file.
+type Symbol struct {
+	Name      string
+	Kind      string
+	StartLine int
+	EndLine   int
+	Text      string
+}
+
+// CodeSpan locates a chunk inside a source file.
+type CodeSpan struct {
+	Path      string
+	Language  string
+	Symbol    string
+	Kind      string
+	StartLine int
+	EndLine   int
+}
+
+func IsCode(path string) bool {
+	_, ok := codeLanguages[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// ParseCode reads a source file. Go files are split into top-level
+// declarations; files that do not parse, and other languages, keep only
+// their content and are chunked at blank lines by ChunkCode.
+func ParseCode(path string) (Document, error) {
+	b, err := os.ReadFile(path)
+	if !common.IsNilValue(err) {
+		return Document{}, err
+	}
+
+	lang := codeLanguages[strings.ToLower(filepath.Ext(path))]
+	doc := Document{
+		ID:       filepath.Base(path),
+		Path:     path,
+		MIME:     "text/x-" + lang,
+		Content:  string(b),
+		Language: lang,
+	}
+	if lang == "go" {
+		doc.Symbols = goSymbols(path, b)
+	}
+	return doc, nil
+}
+
+func goSymbols(path string, src []byte) []Symbol {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	var out []Symbol
+	add := func(name, kind string, from, to token.Pos) {
+		start, end := fset.Position(from), fset.Position(to)
+		out = append(out, Symbol{
+			Name:      name,
+			Kind:      kind,
+			StartLine: start.Line,
+			EndLine:   end.Line,
+			Text:      string(src[start.Offset:end.Offset]),
+		})
+	}
+
+	if f.Doc != nil {
+		add(f.Name.Name, "package", f.Doc.Pos(), f.Name.End())
+	}
+
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			from := d.Pos()
+			if d.Doc != nil {
+				from = d.Doc.Pos()
+			}
+			name, kind := d.Name.Name, "func"
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name, kind = receiverName(d.Recv.List[0].Type)+"."+name, "method"
+			}
+			add(name, kind, from, d.End())
+
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			from := d.Pos()
+			if d.Doc != nil {
+				from = d.Doc.Pos()
+			}
+			add(strings.Join(specNames(d), ", "), d.Tok.String(), from, d.End())
+		}
+	}
+	return out
+}
+
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	default:
+		return ""
+	}
+}
+
+func specNames(d *ast.GenDecl) []string {
+	var names []string
+	for _, spec := range d.Specs {
+		switch s := spec.(type) {
+		case *ast.TypeSpec:
+			names = append(names, s.Name.Name)
+		case *ast.ValueSpec:
+			for _, n := range s.Names {
+				names = append(names, n.Name)
+			}
+		}
+	}
+	return names
+}
+
+// ChunkCode emits one chunk per symbol, splitting symbols longer than target
+// tokens at line boundaries. Without symbols the file is packed into chunks
+// of up to target tokens that only break at blank lines, unless a single
+// block is itself too long.
+func ChunkCode(doc Document, tok tokenizer.Tokenizer, target int) []Chunk {
+	var out []Chunk
+	emit := func(text string, span CodeSpan) {
+		if strings.TrimSpace(text) == "" {
+			return
+		}
+		span.Path, span.Language = doc.Path, doc.Language
+		out = append(out, Chunk{
+			DocID:   doc.ID,
+			Index:   len(out),
+			Text:    text,
+			ChunkID: "lines-" + common.Itoa(span.StartLine) + "-" + common.Itoa(span.EndLine),
+			Code:    &span,
+		})
+	}
+
+	if len(doc.Symbols) > 0 {
+		for _, sym := range doc.Symbols {
+			for _, piece := range packLines(strings.Split(sym.Text, "\n"), sym.StartLine, tok, target) {
+				emit(piece.text, CodeSpan{Symbol: sym.Name, Kind: sym.Kind, StartLine: piece.start, EndLine: piece.end})
+			}
+		}
+		return out
+	}
+
+	for _, piece := range packLines(strings.Split(doc.Content, "\n"), 1, tok, target) {
+		emit(piece.text, CodeSpan{StartLine: piece.start, EndLine: piece.end})
+	}
+	return out
+}
+
+type lineSpan struct {
+	text       string
+	start, end int
+}
+
+// packLines groups lines into spans of at most target tokens. Spans end at a
+// blank line whenever one is available, and mid-block only when a single
+// blank-line separated block exceeds target. first is the line number of
+// lines[0].
+func packLines(lines []string, first int, tok tokenizer.Tokenizer, target int) []lineSpan {
+	target = max(1, target)
+
+	var (
+		out       []lineSpan
+		start     = -1
+		tokens    int
+		lastBlank = -1 // last blank line inside the current span
+	)
+	cut := func(end int) {
+		for end > start && strings.TrimSpace(lines[end-1]) == "" {
+			end--
+		}
+		if end > start {
+			out = append(out, lineSpan{
+				text:  strings.Join(lines[start:end], "\n"),
+				start: first + start,
+				end:   first + end - 1,
+			})
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		blank := strings.TrimSpace(lines[i]) == ""
+		if start < 0 {
+			if blank {
+				continue
+			}
+			start, tokens, lastBlank = i, 0, -1
+		}
+
+		n := tok.Count(lines[i])
+		if tokens > 0 && tokens+n > target {
+			at := i
+			if lastBlank > start {
+				at = lastBlank
+			}
+			cut(at)
+			start, tokens, lastBlank = -1, 0, -1
+			i = at - 1
+			continue
+		}
+
+		if blank {
+			lastBlank = i
+		}
+		tokens += n
+	}
+	if start >= 0 {
+		cut(len(lines))
+	}
+	return out
+}
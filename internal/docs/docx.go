@@ -0,0 +1,286 @@
+package docs
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/brunomgama/go_rag/internal/common"
+)
+
+var headingStyleID = regexp.MustCompile(`(?i)^heading\s*([1-9])$`)
+
+// ParseDOCX reads the body of an Office Open XML document. Paragraph styles
+// are resolved through word/styles.xml, so localized heading styles still
+// become sections.
+func ParseDOCX(path string) (Document, error) {
+	zr, err := zip.OpenReader(path)
+	if !common.IsNilValue(err) {
+		return Document{}, err
+	}
+	defer zr.Close()
+
+	levels := map[string]int{}
+	if styles, err := openZipFile(&zr.Reader, "word/styles.xml"); err == nil {
+		levels = docxHeadingStyles(styles)
+		styles.Close()
+	}
+
+	body, err := openZipFile(&zr.Reader, "word/document.xml")
+	if !common.IsNilValue(err) {
+		return Document{}, err
+	}
+	defer body.Close()
+
+	sections, err := docxSections(body, levels)
+	if !common.IsNilValue(err) {
+		return Document{}, fmt.Errorf("docx %s: %w", path, err)
+	}
+
+	doc := Document{
+		ID:       filepath.Base(path),
+		Path:     path,
+		MIME:     "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		Content:  joinSections(sections),
+		Sections: sections,
+	}
+	if core, err := openZipFile(&zr.Reader, "docProps/core.xml"); err == nil {
+		doc.Title, doc.Meta = officeProperties(core, docxProperties)
+		core.Close()
+	}
+	return doc, nil
+}
+
+// docxHeadingStyles maps style ids to heading levels, using either the style
+// name ("heading 2") or its outline level.
+func docxHeadingStyles(r io.Reader) map[string]int {
+	var styles struct {
+		Style []struct {
+			ID   string `xml:"styleId,attr"`
+			Name struct {
+				Val string `xml:"val,attr"`
+			} `xml:"name"`
+			PPr struct {
+				OutlineLvl *struct {
+					Val string `xml:"val,attr"`
+				} `xml:"outlineLvl"`
+			} `xml:"pPr"`
+		} `xml:"style"`
+	}
+	levels := map[string]int{}
+	if err := xml.NewDecoder(r).Decode(&styles); err != nil {
+		return levels
+	}
+
+	for _, s := range styles.Style {
+		name := strings.ToLower(strings.TrimSpace(s.Name.Val))
+		switch {
+		case name == "title":
+			levels[s.ID] = 1
+		case headingStyleID.MatchString(name):
+			levels[s.ID], _ = strconv.Atoi(headingStyleID.FindStringSubmatch(name)[1])
+		case s.PPr.OutlineLvl != nil:
+			if lvl, err := strconv.Atoi(s.PPr.OutlineLvl.Val); err == nil && lvl < 9 {
+				levels[s.ID] = lvl + 1
+			}
+		}
+	}
+	return levels
+}
+
+func docxSections(r io.Reader, levels map[string]int) ([]Section, error) {
+	var (
+		b      sectionBuilder
+		para   strings.Builder
+		inText bool
+		style  string
+		level  int
+		isList bool
+		depth  int // table nesting
+		cell   []string
+		row    []string
+	)
+
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				para.Reset()
+				style, level, isList = "", 0, false
+			case "pStyle":
+				style = xmlAttr(t, "val")
+			case "outlineLvl":
+				if lvl, err := strconv.Atoi(xmlAttr(t, "val")); err == nil && lvl < 9 {
+					level = lvl + 1
+				}
+			case "numPr":
+				isList = true
+			case "t":
+				inText = true
+			case "tab":
+				para.WriteString("\t")
+			case "br", "cr":
+				para.WriteString("\n")
+			case "tbl":
+				depth++
+			case "tr":
+				if depth == 1 {
+					row = nil
+				}
+			case "tc":
+				if depth == 1 {
+					cell = nil
+				}
+			}
+
+		case xml.CharData:
+			if inText {
+				para.Write(t)
+			}
+
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text := strings.TrimSpace(para.String())
+				if text == "" {
+					continue
+				}
+				if depth > 0 {
+					cell = append(cell, text)
+					continue
+				}
+				if level == 0 {
+					level = levels[style]
+				}
+				if level == 0 {
+					if m := headingStyleID.FindStringSubmatch(style); m != nil {
+						level, _ = strconv.Atoi(m[1])
+					}
+				}
+				switch {
+				case level > 0:
+					b.heading(level, text)
+				case isList:
+					b.line("- " + text)
+				default:
+					b.line(text)
+				}
+			case "tc":
+				if depth == 1 {
+					row = append(row, strings.Join(cell, " "))
+				}
+			case "tr":
+				if depth == 1 && strings.TrimSpace(strings.Join(row, "")) != "" {
+					b.line(strings.Join(row, " | "))
+				}
+			case "tbl":
+				depth--
+			}
+		}
+	}
+
+	return b.sections(), nil
+}
+
+var docxProperties = map[string]string{
+	"creator":        "author",
+	"lastModifiedBy": "last_modified_by",
+	"modified":       "modified",
+	"created":        "created",
+	"subject":        "subject",
+	"keywords":       "keywords",
+	"description":    "description",
+}
+
+// officeProperties reads the flat property files of OOXML (docProps/core.xml)
+// and ODF (meta.xml). keys maps element local names to Document.Meta keys;
+// <title> always becomes the document title.
+func officeProperties(r io.Reader, keys map[string]string) (string, map[string]string) {
+	var title string
+	meta := map[string]string{}
+
+	dec := xml.NewDecoder(r)
+	var current string
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			current = t.Name.Local
+		case xml.CharData:
+			v := strings.TrimSpace(string(t))
+			if v == "" {
+				continue
+			}
+			if current == "title" {
+				title = v
+				continue
+			}
+			if key, ok := keys[current]; ok {
+				// ODF repeats <meta:keyword> once per keyword
+				if prev := meta[key]; prev != "" && key == "keywords" {
+					v = prev + ", " + v
+				}
+				meta[key] = v
+			}
+		case xml.EndElement:
+			current = ""
+		}
+	}
+
+	if len(meta) == 0 {
+		meta = nil
+	}
+	return title, meta
+}
+
+// maxPartBytes caps the decompressed size of a single part of a DOCX or ODT
+// package, so a small upload cannot inflate into gigabytes of XML.
+var maxPartBytes int64 = 128 << 20
+
+var errPartTooLarge = errors.New("document part exceeds size limit")
+
+// openZipFile opens the named part of a package, refusing parts larger
+// than maxPartBytes. archive/zip fails reads past the size in the header,
+// so a part cannot inflate beyond what it declares.
+func openZipFile(zr *zip.Reader, name string) (io.ReadCloser, error) {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		if f.UncompressedSize64 > uint64(maxPartBytes) {
+			return nil, fmt.Errorf("%w: %s is %d bytes", errPartTooLarge, name, f.UncompressedSize64)
+		}
+		return f.Open()
+	}
+	return nil, fmt.Errorf("%s not found in archive", name)
+}
+
+func xmlAttr(e xml.StartElement, local string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
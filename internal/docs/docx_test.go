@@ -0,0 +1,172 @@
+package docs
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeZipFile builds a package named name from members, written in order.
+func writeZipFile(t *testing.T, name string, members [][2]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, m := range members {
+		w, err := zw.Create(m[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(m[1]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	return path
+}
+
+func checkSections(t *testing.T, got []Section, want []Section) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sections =\n%q\nwant\n%q", got, want)
+	}
+}
+
+const docxStyles = `<?xml version="1.0" encoding="UTF-8"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+  <w:style w:type="paragraph" w:styleId="Titel"><w:name w:val="Title"/></w:style>
+  <w:style w:type="paragraph" w:styleId="berschrift2"><w:name w:val="heading 2"/></w:style>
+  <w:style w:type="paragraph" w:styleId="Kasten"><w:name w:val="Kasten"/><w:pPr><w:outlineLvl w:val="2"/></w:pPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Standard"><w:name w:val="Normal"/></w:style>
+</w:styles>`
+
+const docxBody = `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+  <w:p><w:pPr><w:pStyle w:val="Titel"/></w:pPr><w:r><w:t>Game Rules</w:t></w:r></w:p>
+  <w:p><w:pPr><w:pStyle w:val="Standard"/></w:pPr><w:r><w:t xml:space="preserve">Intro </w:t></w:r><w:r><w:t>text.</w:t></w:r></w:p>
+  <w:p><w:pPr><w:pStyle w:val="berschrift2"/></w:pPr><w:r><w:t>Setup</w:t></w:r></w:p>
+  <w:p><w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>Place the board</w:t></w:r></w:p>
+  <w:p><w:pPr><w:pStyle w:val="Kasten"/></w:pPr><w:r><w:t>Cards</w:t></w:r></w:p>
+  <w:tbl>
+    <w:tr><w:tc><w:p><w:r><w:t>Card</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>Cost</w:t></w:r></w:p></w:tc></w:tr>
+    <w:tr><w:tc><w:p><w:r><w:t>Road</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>1 brick</w:t></w:r></w:p><w:p><w:r><w:t>1 lumber</w:t></w:r></w:p></w:tc></w:tr>
+  </w:tbl>
+  <w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>End</w:t></w:r></w:p>
+  <w:p><w:r><w:t>Bye</w:t><w:tab/><w:t>now</w:t><w:br/><w:t>later</w:t></w:r></w:p>
+  <w:p><w:pPr><w:outlineLvl w:val="1"/></w:pPr><w:r><w:t>Scoring</w:t></w:r></w:p>
+  <w:p><w:r><w:t>Ten points win.</w:t></w:r></w:p>
+</w:body></w:document>`
+
+const docxCore = `<?xml version="1.0" encoding="UTF-8"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/">
+  <dc:title>Catan</dc:title>
+  <dc:creator>Klaus</dc:creator>
+  <cp:lastModifiedBy>Editor</cp:lastModifiedBy>
+  <dcterms:modified>2020-01-02T03:04:05Z</dcterms:modified>
+  <cp:keywords>dice, trade</cp:keywords>
+</cp:coreProperties>`
+
+func TestParseDOCX(t *testing.T) {
+	path := writeZipFile(t, "rules.docx", [][2]string{
+		{"[Content_Types].xml", `<Types/>`},
+		{"word/document.xml", docxBody},
+		{"word/styles.xml", docxStyles},
+		{"docProps/core.xml", docxCore},
+	})
+	doc, err := ParseDOCX(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkSections(t, doc.Sections, []Section{
+		{Heading: []string{"Game Rules"}, Text: "Game Rules\nIntro text."},
+		{Heading: []string{"Game Rules", "Setup"}, Text: "Setup\n- Place the board"},
+		{Heading: []string{"Game Rules", "Setup", "Cards"}, Text: "Cards\nCard | Cost\nRoad | 1 brick 1 lumber"},
+		{Heading: []string{"End"}, Text: "End\nBye\tnow\nlater"},
+		{Heading: []string{"End", "Scoring"}, Text: "Scoring\nTen points win."},
+	})
+	if doc.Title != "Catan" {
+		t.Errorf("title = %q", doc.Title)
+	}
+	want := map[string]string{
+		"author":           "Klaus",
+		"last_modified_by": "Editor",
+		"modified":         "2020-01-02T03:04:05Z",
+		"keywords":         "dice, trade",
+	}
+	if !reflect.DeepEqual(doc.Meta, want) {
+		t.Errorf("meta = %v, want %v", doc.Meta, want)
+	}
+}
+
+func TestParseDOCXWithoutStyles(t *testing.T) {
+	path := writeZipFile(t, "plain.docx", [][2]string{{"word/document.xml", docxBody}})
+	doc, err := ParseDOCX(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only the style ids that spell a heading level remain headings
+	if got := sectionPaths(doc.Sections); !reflect.DeepEqual(got, []string{"", "End", "End > Scoring"}) {
+		t.Errorf("sections = %q", got)
+	}
+	if doc.Title != "" || doc.Meta != nil {
+		t.Errorf("title %q, meta %v without core.xml", doc.Title, doc.Meta)
+	}
+}
+
+func TestParseDOCXMissingBody(t *testing.T) {
+	path := writeZipFile(t, "empty.docx", [][2]string{{"word/styles.xml", docxStyles}})
+	if _, err := ParseDOCX(path); err == nil {
+		t.Error("no error for a package without word/document.xml")
+	}
+}
+
+func TestParseDOCXPartLimit(t *testing.T) {
+	defer func(n int64) { maxPartBytes = n }(maxPartBytes)
+	maxPartBytes = 1 << 10
+
+	body := strings.Replace(docxBody, "Ten points win.", strings.Repeat("word ", 1<<10), 1)
+	path := writeZipFile(t, "big.docx", [][2]string{{"word/document.xml", body}})
+	if _, err := ParseDOCX(path); !errors.Is(err, errPartTooLarge) {
+		t.Errorf("err = %v, want errPartTooLarge", err)
+	}
+
+	// a part that inflates beyond the size in its header fails to read
+	path = filepath.Join(t.TempDir(), "lying.docx")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compressed bytes.Buffer
+	fw, _ := flate.NewWriter(&compressed, flate.BestCompression)
+	fw.Write([]byte(body))
+	fw.Close()
+	zw := zip.NewWriter(f)
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               "word/document.xml",
+		Method:             zip.Deflate,
+		CRC32:              crc32.ChecksumIEEE([]byte(body)),
+		CompressedSize64:   uint64(compressed.Len()),
+		UncompressedSize64: 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(compressed.Bytes())
+	zw.Close()
+	f.Close()
+	if _, err := ParseDOCX(path); !errors.Is(err, zip.ErrFormat) {
+		t.Errorf("lying header: err = %v, want zip.ErrFormat", err)
+	}
+}
@@ -0,0 +1,318 @@
+package docs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/brunomgama/go_rag/internal/common"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skipped elements never carry document content.
+var skipped = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Aside: true,
+	atom.Form: true, atom.Button: true, atom.Select: true, atom.Svg: true,
+	atom.Iframe: true, atom.Head: true,
+}
+
+// chrome elements are the page banner and footer, unless they head or close
+// a sectioning element, as in <article><header><h1>Title</h1></header>.
+var chrome = map[atom.Atom]bool{atom.Header: true, atom.Footer: true}
+
+var sectioning = map[atom.Atom]bool{
+	atom.Main: true, atom.Article: true, atom.Section: true,
+}
+
+// blocks end the current line before and after their content.
+var blocks = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.Blockquote: true, atom.Pre: true, atom.Ul: true, atom.Ol: true, atom.Dl: true,
+	atom.Dt: true, atom.Dd: true, atom.Figure: true, atom.Figcaption: true, atom.Hr: true,
+	atom.Address: true, atom.Details: true, atom.Summary: true,
+}
+
+var headingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+func ParseHTML(path string) (Document, error) {
+	f, err := os.Open(path)
+	if !common.IsNilValue(err) {
+		return Document{}, err
+	}
+	defer f.Close()
+
+	root, err := html.Parse(f)
+	if !common.IsNilValue(err) {
+		return Document{}, err
+	}
+
+	w := &htmlWriter{}
+	w.walk(contentRoot(root))
+	sections := w.b.sections()
+
+	title := strings.TrimSpace(collapseSpace(textOf(find(root, atom.Title))))
+	if title == "" {
+		title = strings.TrimSpace(collapseSpace(textOf(find(root, atom.H1))))
+	}
+
+	return Document{
+		ID:       filepath.Base(path),
+		Path:     path,
+		MIME:     "text/html",
+		Content:  joinSections(sections),
+		Sections: sections,
+		Title:    title,
+	}, nil
+}
+
+// contentRoot prefers <main>, then a lone <article>, over the whole <body>,
+// which drops most site chrome that is not marked up as nav/header/footer.
+func contentRoot(root *html.Node) *html.Node {
+	if n := find(root, atom.Main); n != nil {
+		return n
+	}
+	if articles := findAll(root, atom.Article); len(articles) == 1 {
+		return articles[0]
+	}
+	if n := find(root, atom.Body); n != nil {
+		return n
+	}
+	return root
+}
+
+type htmlWriter struct {
+	b    sectionBuilder
+	cur  strings.Builder
+	pre  int
+	list []int // item counter per open list, -1 for unordered
+	// sectioned counts the open sectioning elements
+	sectioned int
+	// prefix is a pending list marker for the next non-empty line
+	prefix string
+}
+
+func (w *htmlWriter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if w.pre > 0 {
+			w.cur.WriteString(n.Data)
+			return
+		}
+		w.cur.WriteString(collapseSpace(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		w.children(n)
+		return
+	}
+
+	if skipped[n.DataAtom] || hidden(n) {
+		return
+	}
+	if chrome[n.DataAtom] && w.sectioned == 0 {
+		return
+	}
+	if sectioning[n.DataAtom] {
+		w.sectioned++
+		defer func() { w.sectioned-- }()
+	}
+
+	if level, ok := headingLevels[n.DataAtom]; ok {
+		w.endLine()
+		if title := strings.TrimSpace(collapseSpace(textOf(n))); title != "" {
+			w.b.heading(level, title)
+		}
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		w.endLine()
+	case atom.Table:
+		w.endLine()
+		w.table(n)
+	case atom.Ul, atom.Ol:
+		w.endLine()
+		counter := -1
+		if n.DataAtom == atom.Ol {
+			counter = 0
+		}
+		w.list = append(w.list, counter)
+		w.children(n)
+		w.list = w.list[:len(w.list)-1]
+		w.endLine()
+	case atom.Li:
+		w.endLine()
+		w.prefix = w.bullet()
+		w.children(n)
+		w.endLine()
+	case atom.Pre:
+		w.endLine()
+		w.pre++
+		w.children(n)
+		w.pre--
+		w.endLine()
+	case atom.Img:
+		if alt := strings.TrimSpace(attr(n, "alt")); alt != "" {
+			w.cur.WriteString(" " + alt + " ")
+		}
+	default:
+		if blocks[n.DataAtom] {
+			w.endLine()
+			w.children(n)
+			w.endLine()
+			return
+		}
+		w.children(n)
+	}
+}
+
+func (w *htmlWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+	}
+}
+
+func (w *htmlWriter) bullet() string {
+	depth := len(w.list)
+	if depth == 0 {
+		return "- "
+	}
+	indent := strings.Repeat("  ", depth-1)
+	if w.list[depth-1] < 0 {
+		return indent + "- "
+	}
+	w.list[depth-1]++
+	return indent + common.Itoa(w.list[depth-1]) + ". "
+}
+
+// table renders each row as cells joined by " | ", which keeps rows
+// together when chunked and stays readable in prompts.
+func (w *htmlWriter) table(n *html.Node) {
+	for _, tr := range findAll(n, atom.Tr) {
+		var cells []string
+		for c := tr.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && (c.DataAtom == atom.Td || c.DataAtom == atom.Th) {
+				cells = append(cells, strings.TrimSpace(collapseSpace(textOf(c))))
+			}
+		}
+		if strings.TrimSpace(strings.Join(cells, "")) != "" {
+			w.b.line(strings.Join(cells, " | "))
+		}
+	}
+}
+
+func (w *htmlWriter) endLine() {
+	line := w.cur.String()
+	w.cur.Reset()
+	if w.pre == 0 {
+		line = strings.TrimSpace(line)
+	}
+	if strings.TrimSpace(line) != "" {
+		w.b.line(w.prefix + line)
+		w.prefix = ""
+	}
+}
+
+func hidden(n *html.Node) bool {
+	if _, ok := hasAttr(n, "hidden"); ok {
+		return true
+	}
+	if attr(n, "aria-hidden") == "true" {
+		return true
+	}
+	switch attr(n, "role") {
+	case "navigation", "banner", "contentinfo", "search":
+		return true
+	}
+	return false
+}
+
+func attr(n *html.Node, key string) string {
+	v, _ := hasAttr(n, key)
+	return v
+}
+
+func hasAttr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func find(n *html.Node, a atom.Atom) *html.Node {
+	if n == nil {
+		return nil
+	}
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := find(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func findAll(n *html.Node, a atom.Atom) []*html.Node {
+	var out []*html.Node
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == a {
+			out = append(out, n)
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(n)
+	return out
+}
+
+func textOf(n *html.Node) string {
+	if n == nil {
+		return ""
+	}
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && skipped[c.DataAtom] && c.DataAtom != atom.Head {
+			continue
+		}
+		sb.WriteString(textOf(c))
+	}
+	return sb.String()
+}
+
+func collapseSpace(s string) string {
+	if s == "" {
+		return s
+	}
+	lead := isSpace(s[0])
+	trail := isSpace(s[len(s)-1])
+	out := strings.Join(strings.Fields(s), " ")
+	if out == "" {
+		return " "
+	}
+	if lead {
+		out = " " + out
+	}
+	if trail {
+		out += " "
+	}
+	return out
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
@@ -0,0 +1,92 @@
+package docs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func sectionPaths(sections []Section) []string {
+	out := make([]string, len(sections))
+	for i, s := range sections {
+		out[i] = s.Path()
+	}
+	return out
+}
+
+func TestParseHTMLArticle(t *testing.T) {
+	page := `<!doctype html>
+<html><head><title>Rules | Games</title><style>p { color: red }</style></head>
+<body>
+<header><a href="/">Home</a></header>
+<nav><a href="/a">Other games</a></nav>
+<article>
+  <header><h1>Title</h1><p>By the editors</p></header>
+  <p>Intro   text
+     over lines.</p>
+  <h2>Sub</h2>
+  <ul><li>first</li><li>second<ol><li>nested</li></ol></li></ul>
+  <table><tr><th>Card</th><th>Cost</th></tr><tr><td>Road</td><td>2</td></tr></table>
+  <div hidden>secret</div>
+  <p>See the board and<br>go.</p>
+  <script>track()</script>
+  <footer>Filed under rules</footer>
+</article>
+<aside>Related links</aside>
+<footer>Copyright 2024</footer>
+</body></html>`
+	doc, err := ParseHTML(writeTemp(t, "rules.html", page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Title != "Rules | Games" || doc.MIME != "text/html" {
+		t.Errorf("title %q, mime %q", doc.Title, doc.MIME)
+	}
+	if got, want := sectionPaths(doc.Sections), []string{"Title", "Title > Sub"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("sections = %q, want %q", got, want)
+	}
+	if got, want := doc.Sections[0].Text, "Title\nBy the editors\nIntro text over lines."; got != want {
+		t.Errorf("first section = %q, want %q", got, want)
+	}
+	want := strings.Join([]string{
+		"Sub",
+		"- first",
+		"- second",
+		"  1. nested",
+		"Card | Cost",
+		"Road | 2",
+		"See the board and",
+		"go.",
+		"Filed under rules",
+	}, "\n")
+	if got := doc.Sections[1].Text; got != want {
+		t.Errorf("second section =\n%s\nwant\n%s", got, want)
+	}
+	for _, chrome := range []string{"Home", "Other games", "Related", "Copyright", "secret", "track", "color"} {
+		if strings.Contains(doc.Content, chrome) {
+			t.Errorf("content holds %q:\n%s", chrome, doc.Content)
+		}
+	}
+}
+
+func TestParseHTMLBody(t *testing.T) {
+	page := `<html><body>
+<header><h1>Site name</h1></header>
+<div role="navigation">Menu</div>
+<section><header><h1>Heading</h1></header><p>Body.</p></section>
+<footer>Contact us</footer>
+</body></html>`
+	doc, err := ParseHTML(writeTemp(t, "page.htm", page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sectionPaths(doc.Sections), []string{"Heading"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sections = %q, want %q", got, want)
+	}
+	if doc.Content != "Heading\nBody." {
+		t.Errorf("content = %q", doc.Content)
+	}
+	if doc.Title != "Site name" {
+		t.Errorf("title = %q, want the first h1", doc.Title)
+	}
+}
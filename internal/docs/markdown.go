@@ -0,0 +1,103 @@
+package docs
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/brunomgama/go_rag/internal/common"
+)
+
+var (
+	atxHeading    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	setextHeading = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	codeFence     = regexp.MustCompile("^ {0,3}(```|~~~)")
+)
+
+func ParseMarkdown(path string) (Document, error) {
+	b, err := os.ReadFile(path)
+	if !common.IsNilValue(err) {
+		return Document{}, err
+	}
+
+	content := string(b)
+	return Document{
+		ID:       filepath.Base(path),
+		Path:     path,
+		MIME:     "text/markdown",
+		Content:  content,
+		Sections: markdownSections(content),
+	}, nil
+}
+
+// markdownSections splits content at ATX and setext headings, ignoring
+// anything inside fenced code blocks. Text before the first heading becomes
+// a section with an empty path.
+func markdownSections(content string) []Section {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	lines = skipFrontMatter(lines)
+
+	var (
+		b     sectionBuilder
+		fence string
+		// inParagraph means the previous line continues paragraph text, in
+		// which case an underline cannot start a setext heading on its own
+		inParagraph bool
+	)
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := codeFence.FindStringSubmatch(line); m != nil {
+			switch {
+			case fence == "":
+				fence = m[1]
+			case fence == m[1]:
+				fence = ""
+			}
+			b.line(line)
+			inParagraph = false
+			continue
+		}
+		if fence != "" {
+			b.line(line)
+			continue
+		}
+
+		if m := atxHeading.FindStringSubmatch(line); m != nil {
+			b.heading(len(m[1]), strings.TrimSpace(m[2]))
+			inParagraph = false
+			continue
+		}
+
+		if i+1 < len(lines) && strings.TrimSpace(line) != "" && !strings.HasPrefix(strings.TrimSpace(line), ">") {
+			if m := setextHeading.FindStringSubmatch(lines[i+1]); m != nil && !inParagraph {
+				level := 1
+				if m[1][0] == '-' {
+					level = 2
+				}
+				b.heading(level, strings.TrimSpace(line))
+				inParagraph = false
+				i++
+				continue
+			}
+		}
+
+		b.line(line)
+		inParagraph = strings.TrimSpace(line) != ""
+	}
+	return b.sections()
+}
+
+func skipFrontMatter(lines []string) []string {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return lines
+	}
+	for i := 1; i < len(lines); i++ {
+		if t := strings.TrimSpace(lines[i]); t == "---" || t == "..." {
+			return lines[i+1:]
+		}
+	}
+	return lines
+}
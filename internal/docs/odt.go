@@ -0,0 +1,201 @@
+package docs
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/brunomgama/go_rag/internal/common"
+)
+
+var odtProperties = map[string]string{
+	"initial-creator": "author",
+	"creator":         "last_modified_by",
+	"date":            "modified",
+	"creation-date":   "created",
+	"subject":         "subject",
+	"keyword":         "keywords",
+	"description":     "description",
+}
+
+// odtSkipped elements hold annotations or change tracking, not body text.
+var odtSkipped = map[string]bool{
+	"annotation":       true,
+	"tracked-changes":  true,
+	"note-citation":    true,
+	"sequence-decls":   true,
+	"table-of-content": true,
+}
+
+func ParseODT(path string) (Document, error) {
+	zr, err := zip.OpenReader(path)
+	if !common.IsNilValue(err) {
+		return Document{}, err
+	}
+	defer zr.Close()
+
+	content, err := openZipFile(&zr.Reader, "content.xml")
+	if !common.IsNilValue(err) {
+		return Document{}, err
+	}
+	defer content.Close()
+
+	sections, err := odtSections(content)
+	if !common.IsNilValue(err) {
+		return Document{}, fmt.Errorf("odt %s: %w", path, err)
+	}
+
+	doc := Document{
+		ID:       filepath.Base(path),
+		Path:     path,
+		MIME:     "application/vnd.oasis.opendocument.text",
+		Content:  joinSections(sections),
+		Sections: sections,
+	}
+	if meta, err := openZipFile(&zr.Reader, "meta.xml"); err == nil {
+		doc.Title, doc.Meta = officeProperties(meta, odtProperties)
+		meta.Close()
+	}
+	return doc, nil
+}
+
+func odtSections(r io.Reader) ([]Section, error) {
+	var (
+		b       sectionBuilder
+		para    strings.Builder
+		inBody  bool
+		inPara  int // text:p / text:h nesting, notes can hold paragraphs
+		heading int
+		lists   int
+		skip    int
+		depth   int // table nesting
+		cell    []string
+		row     []string
+	)
+
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := t.Name.Local
+			if name == "text" && t.Name.Space == "urn:oasis:names:tc:opendocument:xmlns:office:1.0" {
+				inBody = true
+			}
+			if !inBody {
+				continue
+			}
+			if skip > 0 || odtSkipped[name] {
+				skip++
+				continue
+			}
+			switch name {
+			case "h":
+				if inPara == 0 {
+					para.Reset()
+					heading = 1
+					if lvl, err := strconv.Atoi(xmlAttr(t, "outline-level")); err == nil && lvl > 0 {
+						heading = lvl
+					}
+				}
+				inPara++
+			case "p":
+				if inPara == 0 {
+					para.Reset()
+					heading = 0
+				} else {
+					para.WriteString(" ")
+				}
+				inPara++
+			case "s":
+				n := 1
+				if c, err := strconv.Atoi(xmlAttr(t, "c")); err == nil && c > 0 {
+					n = c
+				}
+				para.WriteString(strings.Repeat(" ", n))
+			case "tab":
+				para.WriteString("\t")
+			case "line-break":
+				para.WriteString("\n")
+			case "list":
+				lists++
+			case "table":
+				depth++
+			case "table-row":
+				if depth == 1 {
+					row = nil
+				}
+			case "table-cell":
+				if depth == 1 {
+					cell = nil
+				}
+			}
+
+		case xml.CharData:
+			if inBody && skip == 0 && inPara > 0 {
+				para.Write(t)
+			}
+
+		case xml.EndElement:
+			name := t.Name.Local
+			if !inBody {
+				continue
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+			switch name {
+			case "text":
+				if t.Name.Space == "urn:oasis:names:tc:opendocument:xmlns:office:1.0" {
+					inBody = false
+				}
+			case "h", "p":
+				inPara--
+				if inPara > 0 {
+					continue
+				}
+				text := strings.TrimSpace(para.String())
+				if text == "" {
+					continue
+				}
+				switch {
+				case depth > 0:
+					cell = append(cell, text)
+				case name == "h":
+					b.heading(heading, text)
+				case lists > 0:
+					b.line(strings.Repeat("  ", lists-1) + "- " + text)
+				default:
+					b.line(text)
+				}
+			case "list":
+				lists--
+			case "table-cell":
+				if depth == 1 {
+					row = append(row, strings.Join(cell, " "))
+				}
+			case "table-row":
+				if depth == 1 && strings.TrimSpace(strings.Join(row, "")) != "" {
+					b.line(strings.Join(row, " | "))
+				}
+			case "table":
+				depth--
+			}
+		}
+	}
+
+	return b.sections(), nil
+}
@@ -0,0 +1,93 @@
+package docs
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const odtContent = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0">
+  <office:automatic-styles><text:p>not body text</text:p></office:automatic-styles>
+  <office:body><office:text>
+    <text:sequence-decls><text:sequence-decl text:name="Figure"/></text:sequence-decls>
+    <text:h text:outline-level="1">Game Rules</text:h>
+    <text:p>Intro<text:s text:c="2"/>text<office:annotation><text:p>check this</text:p></office:annotation>.</text:p>
+    <text:h text:outline-level="2">Setup</text:h>
+    <text:list>
+      <text:list-item><text:p>Place the board</text:p>
+        <text:list><text:list-item><text:p>Hexes first</text:p></text:list-item></text:list>
+      </text:list-item>
+    </text:list>
+    <table:table>
+      <table:table-row><table:table-cell><text:p>Card</text:p></table:table-cell><table:table-cell><text:p>Cost</text:p></table:table-cell></table:table-row>
+      <table:table-row><table:table-cell><text:p>Road</text:p></table:table-cell><table:table-cell><text:p>1 brick</text:p><text:p>1 lumber</text:p></table:table-cell></table:table-row>
+    </table:table>
+    <text:p>Bye<text:tab/>now<text:line-break/>later</text:p>
+    <text:h>End</text:h>
+    <text:p>Ten points win.</text:p>
+  </office:text></office:body>
+</office:document-content>`
+
+const odtMeta = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-meta xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <office:meta>
+    <dc:title>Catan</dc:title>
+    <meta:initial-creator>Klaus</meta:initial-creator>
+    <dc:creator>Editor</dc:creator>
+    <dc:date>2020-01-02T03:04:05</dc:date>
+    <meta:creation-date>2019-12-31T00:00:00</meta:creation-date>
+    <meta:keyword>dice</meta:keyword>
+    <meta:keyword>trade</meta:keyword>
+  </office:meta>
+</office:document-meta>`
+
+func TestParseODT(t *testing.T) {
+	path := writeZipFile(t, "rules.odt", [][2]string{
+		{"mimetype", "application/vnd.oasis.opendocument.text"},
+		{"content.xml", odtContent},
+		{"meta.xml", odtMeta},
+	})
+	doc, err := ParseODT(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkSections(t, doc.Sections, []Section{
+		{Heading: []string{"Game Rules"}, Text: "Game Rules\nIntro  text."},
+		{Heading: []string{"Game Rules", "Setup"}, Text: strings.Join([]string{
+			"Setup",
+			"- Place the board",
+			"  - Hexes first",
+			"Card | Cost",
+			"Road | 1 brick 1 lumber",
+			"Bye\tnow\nlater",
+		}, "\n")},
+		{Heading: []string{"End"}, Text: "End\nTen points win."},
+	})
+	if doc.Title != "Catan" {
+		t.Errorf("title = %q", doc.Title)
+	}
+	want := map[string]string{
+		"author":           "Klaus",
+		"last_modified_by": "Editor",
+		"modified":         "2020-01-02T03:04:05",
+		"created":          "2019-12-31T00:00:00",
+		"keywords":         "dice, trade",
+	}
+	if !reflect.DeepEqual(doc.Meta, want) {
+		t.Errorf("meta = %v, want %v", doc.Meta, want)
+	}
+}
+
+func TestParseODTPartLimit(t *testing.T) {
+	defer func(n int64) { maxPartBytes = n }(maxPartBytes)
+	maxPartBytes = 1 << 10
+
+	content := strings.Replace(odtContent, "Ten points win.", strings.Repeat("word ", 1<<10), 1)
+	path := writeZipFile(t, "big.odt", [][2]string{{"content.xml", content}})
+	if _, err := ParseODT(path); !errors.Is(err, errPartTooLarge) {
+		t.Errorf("err = %v, want errPartTooLarge", err)
+	}
+}
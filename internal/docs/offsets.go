@@ -0,0 +1,64 @@
+package docs
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+type span struct{ start, end int }
+
+// lineMap translates byte offsets in text derived from a document, such as
+// a section body or a page, to byte offsets in the document content.
+type lineMap struct {
+	text    []int // line starts in the derived text
+	content []int // where the same lines start in content
+}
+
+// mapLines finds every line of text in content, in order and starting at
+// from, and returns the content offset after the last one. Parsers keep
+// lines verbatim even when they drop markup around them, such as the "#" of
+// a Markdown heading, so a line that cannot be found means text was
+// rewritten and cannot be located.
+func mapLines(content, text string, from int) (lineMap, int, bool) {
+	var (
+		m   lineMap
+		pos int
+	)
+	for _, line := range strings.SplitAfter(text, "\n") {
+		l := strings.TrimSuffix(line, "\n")
+		at := strings.Index(content[from:], l)
+		if at < 0 {
+			return lineMap{}, from, false
+		}
+		m.text = append(m.text, pos)
+		m.content = append(m.content, from+at)
+		from += at + len(l)
+		pos += len(line)
+	}
+	return m, from, true
+}
+
+func (m lineMap) at(offset int) int {
+	i := sort.SearchInts(m.text, offset+1) - 1
+	return m.content[i] + offset - m.text[i]
+}
+
+// runeCursor converts byte offsets into text to rune offsets. Chunks arrive
+// roughly in order, so it only counts the runes between the previous
+// offset and the next one.
+type runeCursor struct {
+	text  string
+	bytes int
+	runes int
+}
+
+func (c *runeCursor) at(offset int) int {
+	if offset >= c.bytes {
+		c.runes += utf8.RuneCountInString(c.text[c.bytes:offset])
+	} else {
+		c.runes -= utf8.RuneCountInString(c.text[offset:c.bytes])
+	}
+	c.bytes = offset
+	return c.runes
+}
@@ -0,0 +1,125 @@
+package docs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brunomgama/go_rag/internal/tokenizer"
+)
+
+// splitters are run over every document: the token splitter rejoins words
+// with single spaces, the sentence splitter cuts text verbatim.
+var splitters = map[string]Splitter{
+	"tokens":    TokenSplitter(tokenizer.Words{}, 12, 3),
+	"sentences": SentenceSplitter(tokenizer.Words{}, 12, 3),
+}
+
+// checkSpans asserts every chunk's Span selects its text from doc.Content,
+// and for paged documents from its page's text too.
+func checkSpans(t *testing.T, doc Document) {
+	t.Helper()
+	content := []rune(doc.Content)
+	for name, split := range splitters {
+		chunks, err := Split(doc, split)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chunks) < 2 {
+			t.Fatalf("%s: got %d chunks, want several", name, len(chunks))
+		}
+		for _, c := range chunks {
+			if c.Span == nil {
+				t.Errorf("%s: chunk %s has no span", name, c.ChunkID)
+				continue
+			}
+			if c.Span.Start < 0 || c.Span.End > len(content) || c.Span.Start >= c.Span.End {
+				t.Errorf("%s: chunk %s span %+v outside content of %d runes", name, c.ChunkID, *c.Span, len(content))
+				continue
+			}
+			sameText(t, name+" "+c.ChunkID, string(content[c.Span.Start:c.Span.End]), c.Text)
+
+			if c.Page == 0 {
+				continue
+			}
+			page := []rune(doc.PageText[c.Page-1])
+			if c.Span.PageEnd > len(page) || c.Span.PageStart >= c.Span.PageEnd {
+				t.Errorf("%s: chunk %s page span %+v outside page of %d runes", name, c.ChunkID, *c.Span, len(page))
+				continue
+			}
+			sameText(t, name+" "+c.ChunkID+" on its page", string(page[c.Span.PageStart:c.Span.PageEnd]), c.Text)
+		}
+	}
+}
+
+func sameText(t *testing.T, what, source, chunk string) {
+	t.Helper()
+	if source != chunk && strings.Join(strings.Fields(source), " ") != chunk {
+		t.Errorf("%s: span selects %q, chunk is %q", what, source, chunk)
+	}
+}
+
+func writeTemp(t *testing.T, name, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSpansPlainText(t *testing.T) {
+	doc, err := ParseFile(writeTemp(t, "notes.txt", strings.Repeat("Café crème für zwei Personen — ohne Zucker.  Très bien!\n\n", 6)), ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSpans(t, doc)
+}
+
+func TestSpansMarkdown(t *testing.T) {
+	md := `# Überblick
+
+Spieler würfeln 🎲 und ziehen. Wer zuerst ankommt, gewinnt das Spiel.
+
+## Aufbau
+
+- Lege das Brett aus.
+- Mische die Karten – alle.
+
+Jeder Spieler nimmt eine Figur. Das jüngste Kind beginnt.
+
+## Ende
+
+Das Spiel endet, wenn alle Straßen verkauft sind. Zählt dann das Geld.
+`
+	doc, err := ParseMarkdown(writeTemp(t, "rules.md", md))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Sections) < 3 {
+		t.Fatalf("got %d sections", len(doc.Sections))
+	}
+	checkSpans(t, doc)
+}
+
+func TestSpansPagedText(t *testing.T) {
+	pages := []string{
+		"Erste Seite mit Umlauten: äöü.\nZweite Zeile der ersten Seite hat mehr Wörter.",
+		"",
+		"Dritte Seite 🎲 folgt nach einer leeren Seite. Sie hat\nzwei Zeilen und endet hier.",
+	}
+	doc := Document{ID: "p.pdf", MIME: "application/pdf", PageText: pages}
+	for _, p := range pages {
+		doc.Content += p + "\n"
+	}
+	checkSpans(t, doc)
+}
+
+func TestSpansPDF(t *testing.T) {
+	doc, err := ParsePDF(filepath.Join("..", "..", "data", "Monopoly GB Instructions.pdf"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSpans(t, doc)
+}
@@ -0,0 +1,42 @@
+package docs
+
+import (
+	"github.com/brunomgama/go_rag/internal/common"
+	"github.com/brunomgama/go_rag/internal/tokenizer"
+)
+
+// Parent is the larger passage a child chunk was cut from, so retrieval can
+// match on small chunks and still hand the model the surrounding context.
+// Children of one parent share it.
+type Parent struct {
+	ID   string
+	Text string
+}
+
+// WithParents cuts every unit into parents of up to target tokens along
+// sentence boundaries and runs child over each parent. Children are
+// numbered across the unit as usual and point back at their parent. Parent
+// ids count up across calls, so use one Splitter per document.
+func WithParents(child Splitter, tok tokenizer.Tokenizer, target int) Splitter {
+	parents := 0
+	return func(docID, text string, page int) ([]Chunk, error) {
+		var out []Chunk
+		for _, p := range chunkSentences(docID, text, page, tok, target, 0) {
+			kids, err := child(docID, text[p.src.start:p.src.end], page)
+			if !common.IsNilValue(err) {
+				return nil, err
+			}
+
+			parent := &Parent{ID: "parent#" + common.Itoa(parents), Text: p.Text}
+			parents++
+			for _, c := range kids {
+				c.Index = len(out)
+				c.ChunkID = chunkID(page, c.Index)
+				c.src = span{p.src.start + c.src.start, p.src.start + c.src.end}
+				c.Parent = parent
+				out = append(out, c)
+			}
+		}
+		return out, nil
+	}
+}
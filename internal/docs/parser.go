@@ -2,6 +2,7 @@ package docs
 
 import (
 	"bytes"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
@@ -17,14 +18,82 @@ type Document struct {
 	MIME     string
 	Content  string
 	PageText []string
+	Sections []Section
+	Records  []Record
+	Symbols  []Symbol
+	Language string
+	// Bundle is the archive name for documents extracted by ParseAll.
+	Bundle string
+	Title  string
+	// Meta holds format specific properties such as author or modified
+	// date. Keys are lower case.
+	Meta map[string]string
+	// Outline is the PDF bookmark tree in document order, and PageHeadings
+	// the outline path in effect on each entry of PageText.
+	Outline      []OutlineItem
+	PageHeadings [][]string
+	// Warnings lists the pages of a PDF that were unreadable, empty or
+	// beyond the page limit.
+	Warnings []PageWarning
 }
 
-func ParseFile(path string) (Document, error) {
+// Section is a run of text under a heading. Heading holds the titles from
+// the outermost heading down, e.g. ["Setup", "Install", "Linux"].
+type Section struct {
+	Heading []string
+	Text    string
+}
+
+func (s Section) Path() string {
+	parts := make([]string, 0, len(s.Heading))
+	for _, h := range s.Heading {
+		if h != "" {
+			parts = append(parts, h)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
+
+// Chapter returns the outermost heading of s.
+func (s Section) Chapter() string {
+	for _, h := range s.Heading {
+		if h != "" {
+			return h
+		}
+	}
+	return ""
+}
+
+// ParseOptions bound the work spent on a single file. MaxPDFPages of 0
+// reads every page.
+type ParseOptions struct {
+	MaxPDFPages int
+}
+
+func ParseFile(path string, opts ParseOptions) (Document, error) {
 	extension := strings.ToLower(filepath.Ext(path))
 
+	if IsCode(path) {
+		return ParseCode(path)
+	}
+
 	switch extension {
 	case ".pdf":
-		return ParsePDF(path)
+		return ParsePDF(path, opts.MaxPDFPages)
+	case ".md", ".markdown":
+		return ParseMarkdown(path)
+	case ".html", ".htm":
+		return ParseHTML(path)
+	case ".docx":
+		return ParseDOCX(path)
+	case ".odt":
+		return ParseODT(path)
+	case ".csv", ".tsv":
+		return ParseCSV(path)
+	case ".json":
+		return ParseJSON(path)
+	case ".jsonl", ".ndjson":
+		return ParseJSONL(path)
 	default:
 		b, err := os.ReadFile(path)
 
@@ -43,7 +112,20 @@ func ParseFile(path string) (Document, error) {
 	}
 }
 
-func ParsePDF(path string) (Document, error) {
+// ParsePDF extracts the text of each page of a PDF, reading at most
+// maxPages pages when maxPages is positive. Pages that fail to extract or
+// carry no text once running headers and footers are removed are kept as
+// empty entries of PageText, so entry i stays page i+1, and are listed in
+// Warnings. A panic of the pdf reader on a malformed file fails only that
+// file.
+func ParsePDF(path string, maxPages int) (doc Document, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered from panic parsing %s: %v", path, r)
+			doc, err = Document{}, fmt.Errorf("malformed pdf %s: %v", path, r)
+		}
+	}()
+
 	file, reader, err := pdf.Open(path)
 
 	if !common.IsNilValue(err) {
@@ -53,28 +135,77 @@ func ParsePDF(path string) (Document, error) {
 	defer file.Close()
 
 	numbPages := reader.NumPage()
-	pages := make([]string, 0, numbPages)
-	var full bytes.Buffer
-
-	for i := 1; i <= numbPages; i++ {
-		page := reader.Page(i)
+	last := numbPages
+	if maxPages > 0 && numbPages > maxPages {
+		last = maxPages
+	}
 
-		if page.V.IsNull() {
-			continue
+	raw := make([]string, 0, last)
+	failed := make(map[int]error)
+	for i := 1; i <= last; i++ {
+		content, err := pageText(reader, i)
+		if !common.IsNilValue(err) {
+			failed[i] = err
 		}
+		raw = append(raw, strings.TrimSpace(content))
+	}
 
-		content, _ := page.GetPlainText(nil)
-		s := strings.TrimSpace(content)
+	// pages are classified after cleanup, so a scanned page that only
+	// carries a running header or page number counts as empty
+	pages := cleanPDFPages(raw)
+	var warnings []PageWarning
+	for i, content := range pages {
+		switch {
+		case failed[i+1] != nil:
+			warnings = append(warnings, PageWarning{Page: i + 1, Kind: PageFailed, Message: failed[i+1].Error()})
+		case content == "" && raw[i] != "":
+			warnings = append(warnings, PageWarning{Page: i + 1, Kind: PageEmpty, Message: "no text besides headers and footers"})
+		case content == "":
+			warnings = append(warnings, PageWarning{Page: i + 1, Kind: PageEmpty, Message: "no text layer"})
+		}
+	}
+	if last < numbPages {
+		warnings = append(warnings, PageWarning{
+			Page:    last + 1,
+			Kind:    PageSkipped,
+			Message: fmt.Sprintf("pages %d-%d not read, limit is %d pages", last+1, numbPages, maxPages),
+		})
+	}
 
-		pages = append(pages, s)
+	var full bytes.Buffer
+	for _, s := range pages {
 		full.WriteString(s + "\n")
 	}
 
+	var (
+		title   string
+		meta    map[string]string
+		outline []OutlineItem
+	)
+	if err := recovered(func() error { title, meta = pdfInfo(reader); return nil }); err != nil {
+		warnings = append(warnings, PageWarning{Kind: PageFailed, Message: "info: " + err.Error()})
+	}
+	if err := recovered(func() error { outline = pdfOutline(reader); return nil }); err != nil {
+		warnings = append(warnings, PageWarning{Kind: PageFailed, Message: "outline: " + err.Error()})
+	}
+	var headings [][]string
+	if len(outline) > 0 {
+		headings = make([][]string, len(pages))
+		for i := range pages {
+			headings[i] = outlineHeadings(outline, i+1)
+		}
+	}
+
 	return Document{
-		ID:       filepath.Base(path),
-		Path:     path,
-		MIME:     "application/pdf",
-		Content:  full.String(),
-		PageText: pages,
+		ID:           filepath.Base(path),
+		Path:         path,
+		MIME:         "application/pdf",
+		Content:      full.String(),
+		PageText:     pages,
+		Title:        title,
+		Meta:         meta,
+		Outline:      outline,
+		PageHeadings: headings,
+		Warnings:     warnings,
 	}, nil
 }
@@ -0,0 +1,134 @@
+package docs
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Lines found on at least repeatedLineShare of the pages of a PDF, after
+// normalizeLine, are taken to be running headers, footers, page numbers or
+// copyright notices. Only the first and last edgeLines non-empty lines of a
+// page are considered, so numbers and labels in the body of a page stay.
+// Short documents are left alone since a line on two of two pages says
+// little.
+const (
+	repeatedLineShare = 0.5
+	repeatedLineMin   = 3
+	edgeLines         = 2
+)
+
+// hyphenBreak matches a word split by a hyphen at the end of a line and
+// continued in lower case on the next one.
+var hyphenBreak = regexp.MustCompile(`(\p{L}+)(-|\x{00AD})[ \t]*\n[ \t]*(\p{Ll}+)`)
+
+// cleanPDFPages drops lines that repeat across pages and rejoins words
+// hyphenated across line breaks.
+func cleanPDFPages(pages []string) []string {
+	repeated := repeatedLines(pages)
+
+	out := make([]string, len(pages))
+	for i, page := range pages {
+		lines := strings.Split(page, "\n")
+		edge := pageEdges(lines)
+		kept := lines[:0]
+		for j, l := range lines {
+			if !edge[j] || !repeated[normalizeLine(l)] {
+				kept = append(kept, l)
+			}
+		}
+		out[i] = strings.Join(kept, "\n")
+	}
+
+	words := vocabulary(out)
+	for i, page := range out {
+		out[i] = strings.TrimSpace(hyphenBreak.ReplaceAllStringFunc(page, func(m string) string {
+			parts := hyphenBreak.FindStringSubmatch(m)
+			return dehyphenate(parts[1], parts[2], parts[3], words)
+		}))
+	}
+	return out
+}
+
+// dehyphenate joins a word split at a line break. The hyphen is kept when
+// the document spells the word with a hyphen elsewhere but never without,
+// as in "award-winning"; soft hyphens are always dropped.
+func dehyphenate(head, hyphen, tail string, words map[string]bool) string {
+	joined := strings.ToLower(head + tail)
+	if hyphen == "-" && words[strings.ToLower(head+"-"+tail)] && !words[joined] {
+		return head + "-" + tail
+	}
+	return head + tail
+}
+
+// vocabulary returns the lower cased words of pages, stripped of
+// surrounding punctuation.
+func vocabulary(pages []string) map[string]bool {
+	words := make(map[string]bool)
+	for _, page := range pages {
+		for _, w := range strings.Fields(page) {
+			w = strings.TrimFunc(w, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+			if w != "" {
+				words[strings.ToLower(w)] = true
+			}
+		}
+	}
+	return words
+}
+
+// repeatedLines returns the normalized lines that occur on enough pages to
+// count as boilerplate.
+func repeatedLines(pages []string) map[string]bool {
+	out := make(map[string]bool)
+	if len(pages) < repeatedLineMin {
+		return out
+	}
+
+	seen := make(map[string]int)
+	for _, page := range pages {
+		lines := strings.Split(page, "\n")
+		onPage := make(map[string]bool)
+		for j := range pageEdges(lines) {
+			if n := normalizeLine(lines[j]); !onPage[n] {
+				onPage[n] = true
+				seen[n]++
+			}
+		}
+	}
+
+	need := max(repeatedLineMin, int(repeatedLineShare*float64(len(pages))+0.5))
+	for line, n := range seen {
+		if n >= need {
+			out[line] = true
+		}
+	}
+	return out
+}
+
+// pageEdges returns the indexes of the first and last edgeLines non-empty
+// lines, where running headers and footers sit.
+func pageEdges(lines []string) map[int]bool {
+	var filled []int
+	for i, l := range lines {
+		if strings.TrimSpace(l) != "" {
+			filled = append(filled, i)
+		}
+	}
+	edge := make(map[int]bool)
+	for k, i := range filled {
+		if k < edgeLines || k >= len(filled)-edgeLines {
+			edge[i] = true
+		}
+	}
+	return edge
+}
+
+// digitRun matches the numbers normalizeLine folds together.
+var digitRun = regexp.MustCompile(`\p{Nd}+`)
+
+// normalizeLine folds case and whitespace and replaces every number with
+// '#', so "Page 3 of 10" and "page 12 of 10" compare equal.
+func normalizeLine(l string) string {
+	l = strings.ToLower(strings.Join(strings.Fields(l), " "))
+	return digitRun.ReplaceAllString(l, "#")
+}
@@ -0,0 +1,72 @@
+package docs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCleanPDFPages(t *testing.T) {
+	// numbers and labels repeat inside the pages too, but only the lines
+	// at the top and bottom are boilerplate
+	var pages, want []string
+	for i, topic := range []string{"Setup", "Turns", "Trading", "Winning"} {
+		body := fmt.Sprintf("%s comes next.\n8\nB\nRoll an 8 to score.", topic)
+		pages = append(pages, fmt.Sprintf("Rules Booklet %d/4\n%s\nCopyright 2020\n%d", i+1, body, i+1))
+		want = append(want, body)
+	}
+	if got := cleanPDFPages(pages); !reflect.DeepEqual(got, want) {
+		t.Errorf("cleanPDFPages =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestCleanPDFPagesShortDocument(t *testing.T) {
+	pages := []string{"Header\nOne.\n1", "Header\nTwo.\n2"}
+	if got := cleanPDFPages(pages); !reflect.DeepEqual(got, pages) {
+		t.Errorf("cleanPDFPages = %q, want the pages unchanged", got)
+	}
+}
+
+func TestCleanPDFPagesHyphens(t *testing.T) {
+	pages := []string{
+		"An award-\nwinning game with a well-\nknown board. Players ex-\nchange cards.",
+		"It is award-winning. Well known, too.",
+	}
+	want := []string{
+		"An award-winning game with a wellknown board. Players exchange cards.",
+		"It is award-winning. Well known, too.",
+	}
+	if got := cleanPDFPages(pages); !reflect.DeepEqual(got, want) {
+		t.Errorf("cleanPDFPages =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestDehyphenate(t *testing.T) {
+	words := vocabulary([]string{"An award-winning, well-known game. Known well. Cooperate!"})
+	tests := []struct {
+		head, hyphen, tail string
+		want               string
+	}{
+		{"ex", "-", "change", "exchange"},
+		{"award", "-", "winning", "award-winning"},
+		{"Award", "-", "winning", "Award-winning"},
+		{"co", "-", "operate", "cooperate"},
+		{"well", "-", "known", "well-known"},
+		{"award", "­", "winning", "awardwinning"},
+	}
+	for _, tt := range tests {
+		if got := dehyphenate(tt.head, tt.hyphen, tt.tail, words); got != tt.want {
+			t.Errorf("dehyphenate(%q, %q, %q) = %q, want %q", tt.head, tt.hyphen, tt.tail, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeLine(t *testing.T) {
+	if a, b := normalizeLine("Page 3  of 10"), normalizeLine(" page 12 of 10 "); a != b {
+		t.Errorf("normalizeLine gives %q and %q", a, b)
+	}
+	if n := normalizeLine(strings.Repeat(" ", 3)); n != "" {
+		t.Errorf("blank line normalizes to %q", n)
+	}
+}
@@ -0,0 +1,188 @@
+package docs
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	pdf "github.com/ledongthuc/pdf"
+)
+
+// OutlineItem is one bookmark of a PDF outline. Level starts at 1 for top
+// level entries; Page is 0 when the destination could not be resolved.
+type OutlineItem struct {
+	Title string
+	Level int
+	Page  int
+}
+
+// pdfProperties maps Info dictionary keys to Document.Meta keys, following
+// the names used for office documents where they overlap.
+var pdfProperties = map[string]string{
+	"Author":       "author",
+	"Subject":      "subject",
+	"Keywords":     "keywords",
+	"Creator":      "application",
+	"Producer":     "producer",
+	"CreationDate": "created",
+	"ModDate":      "modified",
+}
+
+// maxOutlineItems guards against outlines whose Next links loop.
+const maxOutlineItems = 10000
+
+// pdfInfo reads the title and properties of the Info dictionary. Dates are
+// converted to RFC 3339 when they follow the PDF date format.
+func pdfInfo(r *pdf.Reader) (string, map[string]string) {
+	info := r.Trailer().Key("Info")
+	title := strings.TrimSpace(info.Key("Title").Text())
+
+	meta := map[string]string{}
+	for key, name := range pdfProperties {
+		v := strings.TrimSpace(info.Key(key).Text())
+		if v == "" {
+			continue
+		}
+		if strings.HasSuffix(key, "Date") {
+			v = pdfDate(v)
+		}
+		meta[name] = v
+	}
+	return title, meta
+}
+
+var pdfDateFormat = regexp.MustCompile(`^D?:?(\d{4})(\d{2})?(\d{2})?(\d{2})?(\d{2})?(\d{2})?([Zz]|[+-]\d{2}'?\d{2}'?)?`)
+
+// pdfDate converts "D:20200707113028-05'00'" to "2020-07-07T11:30:28-05:00",
+// returning v unchanged when it does not parse.
+func pdfDate(v string) string {
+	m := pdfDateFormat.FindStringSubmatch(v)
+	if m == nil {
+		return v
+	}
+	for i, def := range []string{"", "", "01", "01", "00", "00", "00"} {
+		if i > 0 && m[i] == "" {
+			m[i] = def
+		}
+	}
+	zone := "Z"
+	if z := strings.ReplaceAll(m[7], "'", ""); len(z) == 5 {
+		zone = z[:3] + ":" + z[3:]
+	}
+
+	t, err := time.Parse(time.RFC3339, m[1]+"-"+m[2]+"-"+m[3]+"T"+m[4]+":"+m[5]+":"+m[6]+zone)
+	if err != nil {
+		return v
+	}
+	return t.Format(time.RFC3339)
+}
+
+// pdfOutline flattens the bookmark tree in document order and resolves each
+// destination, direct, through a GoTo action or by name, to a page number.
+func pdfOutline(r *pdf.Reader) []OutlineItem {
+	root := r.Trailer().Key("Root")
+	first := root.Key("Outlines").Key("First")
+	if first.IsNull() {
+		return nil
+	}
+
+	// page dictionaries are matched by their serialized form, since the
+	// reader does not expose object numbers
+	pages := make(map[string]int)
+	for i := 1; i <= r.NumPage(); i++ {
+		if p := r.Page(i); !p.V.IsNull() {
+			pages[p.V.String()] = i
+		}
+	}
+
+	var out []OutlineItem
+	var walk func(item pdf.Value, level int)
+	walk = func(item pdf.Value, level int) {
+		for ; item.Kind() == pdf.Dict && len(out) < maxOutlineItems; item = item.Key("Next") {
+			dest := item.Key("Dest")
+			if dest.IsNull() {
+				if action := item.Key("A"); action.Key("S").Name() == "GoTo" {
+					dest = action.Key("D")
+				}
+			}
+			out = append(out, OutlineItem{
+				Title: strings.TrimSpace(item.Key("Title").Text()),
+				Level: level,
+				Page:  destPage(root, dest, pages),
+			})
+			walk(item.Key("First"), level+1)
+		}
+	}
+	walk(first, 1)
+	return out
+}
+
+// destPage returns the page number a destination points at, or 0.
+func destPage(root, dest pdf.Value, pages map[string]int) int {
+	switch dest.Kind() {
+	case pdf.Name:
+		dest = root.Key("Dests").Key(dest.Name())
+	case pdf.String:
+		dest = lookupName(root.Key("Names").Key("Dests"), dest.RawString(), 0)
+	}
+	if dest.Kind() == pdf.Dict {
+		dest = dest.Key("D")
+	}
+	if dest.Kind() != pdf.Array {
+		return 0
+	}
+
+	target := dest.Index(0)
+	if target.Kind() == pdf.Integer {
+		return int(target.Int64()) + 1 // remote go-to destinations count from 0
+	}
+	return pages[target.String()]
+}
+
+// lookupName searches a name tree for key.
+func lookupName(node pdf.Value, key string, depth int) pdf.Value {
+	if node.IsNull() || depth > 32 {
+		return pdf.Value{}
+	}
+	names := node.Key("Names")
+	for i := 0; i+1 < names.Len(); i += 2 {
+		if names.Index(i).RawString() == key {
+			return names.Index(i + 1)
+		}
+	}
+	kids := node.Key("Kids")
+	for i := 0; i < kids.Len(); i++ {
+		kid := kids.Index(i)
+		if limits := kid.Key("Limits"); limits.Len() == 2 {
+			if key < limits.Index(0).RawString() || key > limits.Index(1).RawString() {
+				continue
+			}
+		}
+		if v := lookupName(kid, key, depth+1); !v.IsNull() {
+			return v
+		}
+	}
+	return pdf.Value{}
+}
+
+// outlineHeadings returns the heading path in effect on page: the titles
+// leading to the last outline entry that starts on or before it.
+func outlineHeadings(outline []OutlineItem, page int) []string {
+	var (
+		stack []string
+		found []string
+	)
+	for _, item := range outline {
+		if item.Level-1 < len(stack) {
+			stack = stack[:item.Level-1]
+		}
+		for len(stack) < item.Level-1 {
+			stack = append(stack, "")
+		}
+		stack = append(stack, item.Title)
+		if item.Page > 0 && item.Page <= page {
+			found = append([]string(nil), stack...)
+		}
+	}
+	return found
+}
@@ -0,0 +1,176 @@
+package docs
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	pdf "github.com/ledongthuc/pdf"
+)
+
+// buildPDF lays out objects 1..n with a cross-reference table. Object 1 is
+// the catalog and object info, when set, the Info dictionary.
+func buildPDF(objects []string, info int) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R", len(objects)+1)
+	if info > 0 {
+		fmt.Fprintf(&b, " /Info %d 0 R", info)
+	}
+	fmt.Fprintf(&b, " >>\nstartxref\n%d\n%%%%EOF\n", xref)
+	return b.Bytes()
+}
+
+// outlinePDF has three pages and an outline reaching them by page
+// reference, GoTo action, named destination, name tree and page index.
+var outlinePDF = buildPDF([]string{
+	/* 1 */ `<< /Type /Catalog /Pages 2 0 R /Outlines 6 0 R /Dests 15 0 R /Names << /Dests 16 0 R >> >>`,
+	/* 2 */ `<< /Type /Pages /Kids [3 0 R 4 0 R 5 0 R] /Count 3 >>`,
+	/* 3 */ `<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /StructParents 0 >>`,
+	/* 4 */ `<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /StructParents 1 >>`,
+	/* 5 */ `<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /StructParents 2 >>`,
+	/* 6 */ `<< /Type /Outlines /First 7 0 R /Last 10 0 R /Count 6 >>`,
+	/* 7 */ `<< /Title (Setup) /Parent 6 0 R /Next 10 0 R /First 8 0 R /Last 9 0 R /Dest [3 0 R /Fit] >>`,
+	/* 8 */ `<< /Title (Board) /Parent 7 0 R /Next 9 0 R /A << /S /GoTo /D (board) >> >>`,
+	/* 9 */ `<< /Title (Cards) /Parent 7 0 R /Dest /cards >>`,
+	/* 10 */ `<< /Title (Play) /Parent 6 0 R /First 11 0 R /Last 12 0 R /A << /S /GoTo /D (play) >> >>`,
+	/* 11 */ `<< /Title (Unknown) /Parent 10 0 R /Next 12 0 R /Dest (missing) >>`,
+	/* 12 */ `<< /Title (Remote) /Parent 10 0 R /Dest [2 /Fit] >>`,
+	/* 13 */ `<< /Title (Rules) /Author (Klaus) /CreationDate (D:20200707113028-05'00') /ModDate (D:2021) /Producer (  ) >>`,
+	/* 14 */ `<< /D [4 0 R /Fit] >>`,
+	/* 15 */ `<< /cards [4 0 R /XYZ 0 792 0] >>`,
+	/* 16 */ `<< /Kids [17 0 R 18 0 R] >>`,
+	/* 17 */ `<< /Limits [(a) (c)] /Names [(alpha) [3 0 R /Fit] (board) 14 0 R] >>`,
+	/* 18 */ `<< /Limits [(m) (z)] /Names [(play) [5 0 R /Fit]] >>`,
+}, 13)
+
+func openOutlinePDF(t *testing.T) *pdf.Reader {
+	t.Helper()
+	r, err := pdf.NewReader(bytes.NewReader(outlinePDF), int64(len(outlinePDF)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestPDFOutline(t *testing.T) {
+	r := openOutlinePDF(t)
+	want := []OutlineItem{
+		{Title: "Setup", Level: 1, Page: 1},
+		{Title: "Board", Level: 2, Page: 2},
+		{Title: "Cards", Level: 2, Page: 2},
+		{Title: "Play", Level: 1, Page: 3},
+		{Title: "Unknown", Level: 2, Page: 0},
+		{Title: "Remote", Level: 2, Page: 3},
+	}
+	if got := pdfOutline(r); !reflect.DeepEqual(got, want) {
+		t.Errorf("outline =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestLookupName(t *testing.T) {
+	tree := openOutlinePDF(t).Trailer().Key("Root").Key("Names").Key("Dests")
+	tests := []struct {
+		key  string
+		kind pdf.ValueKind
+	}{
+		{"alpha", pdf.Array},
+		{"board", pdf.Dict},
+		{"play", pdf.Array},
+		{"bz", pdf.Null},  // within the limits of the first kid, not in it
+		{"k", pdf.Null},   // between the kids' limits
+		{"zzz", pdf.Null}, // past every limit
+	}
+	for _, tt := range tests {
+		if got := lookupName(tree, tt.key, 0); got.Kind() != tt.kind {
+			t.Errorf("lookupName(%q) = %v, want kind %v", tt.key, got, tt.kind)
+		}
+	}
+	if got := lookupName(tree, "play", 33); !got.IsNull() {
+		t.Errorf("lookupName past the depth limit = %v", got)
+	}
+}
+
+func TestPDFInfo(t *testing.T) {
+	title, meta := pdfInfo(openOutlinePDF(t))
+	if title != "Rules" {
+		t.Errorf("title = %q", title)
+	}
+	want := map[string]string{
+		"author":   "Klaus",
+		"created":  "2020-07-07T11:30:28-05:00",
+		"modified": "2021-01-01T00:00:00Z",
+	}
+	if !reflect.DeepEqual(meta, want) {
+		t.Errorf("meta = %v, want %v", meta, want)
+	}
+}
+
+func TestPDFDate(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"D:20200707113028-05'00'", "2020-07-07T11:30:28-05:00"},
+		{"D:20200707113028+05'30", "2020-07-07T11:30:28+05:30"},
+		{"D:20200707113028+0130", "2020-07-07T11:30:28+01:30"},
+		{"D:20200707113028Z", "2020-07-07T11:30:28Z"},
+		{"D:20200707113028Z00'00'", "2020-07-07T11:30:28Z"},
+		{"D:20200707113028", "2020-07-07T11:30:28Z"},
+		{"D:202007071130", "2020-07-07T11:30:00Z"},
+		{"D:2020070711", "2020-07-07T11:00:00Z"},
+		{"D:202007", "2020-07-01T00:00:00Z"},
+		{"D:2020", "2020-01-01T00:00:00Z"},
+		{"20200707", "2020-07-07T00:00:00Z"},
+		{"D:20201332", "D:20201332"},
+		{"July 2020", "July 2020"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := pdfDate(tt.in); got != tt.want {
+			t.Errorf("pdfDate(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestOutlineHeadings(t *testing.T) {
+	outline := []OutlineItem{
+		{Title: "Setup", Level: 1, Page: 2},
+		{Title: "Board", Level: 2, Page: 3},
+		{Title: "Cards", Level: 2, Page: 5},
+		{Title: "Play", Level: 1, Page: 6},
+		{Title: "Deep", Level: 3, Page: 7},
+		{Title: "Unresolved", Level: 2, Page: 0},
+		{Title: "Scoring", Level: 2, Page: 9},
+	}
+	tests := []struct {
+		page int
+		want []string
+	}{
+		{1, nil},
+		{2, []string{"Setup"}},
+		{3, []string{"Setup", "Board"}},
+		{4, []string{"Setup", "Board"}},
+		{5, []string{"Setup", "Cards"}},
+		{6, []string{"Play"}},
+		{7, []string{"Play", "", "Deep"}},
+		{8, []string{"Play", "", "Deep"}},
+		{9, []string{"Play", "Scoring"}},
+	}
+	for _, tt := range tests {
+		if got := outlineHeadings(outline, tt.page); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("outlineHeadings(page %d) = %q, want %q", tt.page, got, tt.want)
+		}
+	}
+	if got := outlineHeadings(nil, 3); got != nil {
+		t.Errorf("outlineHeadings without an outline = %q", got)
+	}
+}
@@ -0,0 +1,52 @@
+package docs
+
+import (
+	"errors"
+	"fmt"
+
+	pdf "github.com/ledongthuc/pdf"
+)
+
+// Kinds of PageWarning.
+const (
+	PageFailed  = "failed"  // text extraction failed or panicked
+	PageEmpty   = "empty"   // no text layer, e.g. a scanned page
+	PageSkipped = "skipped" // beyond the configured page limit
+)
+
+// PageWarning records a problem with one page that did not stop the rest
+// of the document from being parsed. Page 0 refers to the document as a
+// whole, e.g. an unreadable outline.
+type PageWarning struct {
+	Page    int    `json:"page"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+var errNoPage = errors.New("missing page object")
+
+// recovered runs f, turning a panic of the pdf reader on malformed input
+// into an error.
+func recovered(f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed pdf: %v", r)
+		}
+	}()
+	return f()
+}
+
+// pageText extracts the plain text of page i.
+func pageText(r *pdf.Reader, i int) (string, error) {
+	var text string
+	err := recovered(func() error {
+		page := r.Page(i)
+		if page.V.IsNull() {
+			return errNoPage
+		}
+		var err error
+		text, err = page.GetPlainText(nil)
+		return err
+	})
+	return text, err
+}
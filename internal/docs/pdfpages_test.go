@@ -0,0 +1,151 @@
+package docs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// textPDF builds a PDF with one page per entry of pages, each line of an
+// entry drawn on its own row. An empty entry makes a page without text.
+func textPDF(pages [][]string) []byte {
+	kids := make([]string, len(pages))
+	objects := []string{
+		`<< /Type /Catalog /Pages 2 0 R >>`,
+		fmt.Sprintf(`<< /Type /Pages /Kids [%%s] /Count %d >>`, len(pages)),
+		`<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>`,
+	}
+	for i, lines := range pages {
+		var content strings.Builder
+		for j, line := range lines {
+			fmt.Fprintf(&content, "BT /F1 12 Tf 72 %d Td (%s) Tj ET\n", 720-20*j, line)
+		}
+		page := len(objects) + 1
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+		objects = append(objects,
+			fmt.Sprintf(`<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>`, page+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+	objects[1] = fmt.Sprintf(objects[1], strings.Join(kids, " "))
+	return buildPDF(objects, 0)
+}
+
+func writePDF(t *testing.T, b []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "doc.pdf")
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParsePDFEmptyPages(t *testing.T) {
+	path := writePDF(t, textPDF([][]string{
+		{"Game Rules", "Setup the board before play.", "1"},
+		{"Game Rules", "2"},
+		{},
+		{"Game Rules", "Roll both dice on your turn.", "4"},
+	}))
+
+	doc, err := ParsePDF(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.PageText) != 4 {
+		t.Fatalf("got %d pages, want 4", len(doc.PageText))
+	}
+	want := []PageWarning{
+		{Page: 2, Kind: PageEmpty, Message: "no text besides headers and footers"},
+		{Page: 3, Kind: PageEmpty, Message: "no text layer"},
+	}
+	if !reflect.DeepEqual(doc.Warnings, want) {
+		t.Errorf("warnings = %+v, want %+v", doc.Warnings, want)
+	}
+	if !strings.Contains(doc.PageText[3], "Roll both dice") || strings.Contains(doc.PageText[3], "Game Rules") {
+		t.Errorf("page 4 = %q", doc.PageText[3])
+	}
+}
+
+func TestParsePDFMaxPages(t *testing.T) {
+	path := writePDF(t, textPDF([][]string{
+		{"First page."}, {"Second page."}, {"Third page."}, {"Fourth page."},
+	}))
+
+	doc, err := ParsePDF(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.PageText) != 2 || strings.Contains(doc.Content, "Third") {
+		t.Fatalf("read %d pages: %q", len(doc.PageText), doc.Content)
+	}
+	want := []PageWarning{{Page: 3, Kind: PageSkipped, Message: "pages 3-4 not read, limit is 2 pages"}}
+	if !reflect.DeepEqual(doc.Warnings, want) {
+		t.Errorf("warnings = %+v, want %+v", doc.Warnings, want)
+	}
+
+	doc, err = ParsePDF(path, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.PageText) != 4 || len(doc.Warnings) != 0 {
+		t.Errorf("at the limit: %d pages, warnings %+v", len(doc.PageText), doc.Warnings)
+	}
+}
+
+func TestParsePDFMissingPage(t *testing.T) {
+	b := textPDF([][]string{{"Only page."}})
+	b = []byte(strings.Replace(string(b), "/Count 1", "/Count 2", 1))
+
+	doc, err := ParsePDF(writePDF(t, b), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PageWarning{{Page: 2, Kind: PageFailed, Message: errNoPage.Error()}}
+	if !reflect.DeepEqual(doc.Warnings, want) {
+		t.Errorf("warnings = %+v, want %+v", doc.Warnings, want)
+	}
+	if len(doc.PageText) != 2 || doc.PageText[1] != "" {
+		t.Errorf("pages = %q", doc.PageText)
+	}
+}
+
+func TestRecovered(t *testing.T) {
+	err := recovered(func() error { panic("bad xref") })
+	if err == nil || err.Error() != "malformed pdf: bad xref" {
+		t.Errorf("err = %v", err)
+	}
+	if err := recovered(func() error { return errNoPage }); err != errNoPage {
+		t.Errorf("err = %v, want %v", err, errNoPage)
+	}
+}
+
+func TestParsePDFFailedPage(t *testing.T) {
+	// the second Tj of page 1 has no operand
+	path := writePDF(t, textPDF([][]string{{"Broken) Tj Tj (page"}, {"Good page."}}))
+
+	doc, err := ParsePDF(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Warnings) != 1 || doc.Warnings[0].Page != 1 || doc.Warnings[0].Kind != PageFailed {
+		t.Fatalf("warnings = %+v", doc.Warnings)
+	}
+	if doc.PageText[0] != "" || !strings.Contains(doc.PageText[1], "Good page.") {
+		t.Errorf("pages = %q", doc.PageText)
+	}
+}
+
+func TestParsePDFPanic(t *testing.T) {
+	// a negative xref size makes the pdf reader slice out of range
+	b := regexp.MustCompile(`/Size \d+`).ReplaceAll(textPDF([][]string{{"Only page."}}), []byte("/Size -3"))
+	path := writePDF(t, b)
+
+	_, err := ParsePDF(path, 0)
+	if err == nil || !strings.HasPrefix(err.Error(), "malformed pdf "+path+": ") {
+		t.Fatalf("err = %v, want a malformed pdf error", err)
+	}
+}
@@ -0,0 +1,453 @@
+package docs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/brunomgama/go_rag/internal/common"
+	"github.com/brunomgama/go_rag/internal/tokenizer"
+)
+
+// Record is one row of structured data. Nested JSON objects are flattened
+// into dotted keys ("user.name") so templates and filters can reach them.
+type Record struct {
+	Keys   []string
+	Values map[string]string
+}
+
+// RecordOptions controls how ChunkRecords turns records into chunks.
+type RecordOptions struct {
+	// PerChunk is the number of records per chunk; values below 1 mean 1.
+	PerChunk int
+	// Template is a text/template rendered once per record with the record
+	// values as dot, e.g. "Q: {{.question}}\nA: {{.answer}}". Flattened keys
+	// need index: {{index . "user.name"}}. When empty each record renders
+	// as "key: value" lines.
+	Template string
+	// Fields lists record keys copied into the chunk payload under "fields".
+	Fields []string
+}
+
+func ParseCSV(path string) (Document, error) {
+	b, err := os.ReadFile(path)
+	if !common.IsNilValue(err) {
+		return Document{}, err
+	}
+
+	r := csv.NewReader(bytes.NewReader(b))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+	if strings.EqualFold(filepath.Ext(path), ".tsv") {
+		r.Comma = '\t'
+	}
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return recordDocument(path, "text/csv", string(b), nil), nil
+	}
+	if !common.IsNilValue(err) {
+		return Document{}, fmt.Errorf("csv %s: %w", path, err)
+	}
+	for i, h := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+		if header[i] == "" {
+			header[i] = "column_" + common.Itoa(i+1)
+		}
+	}
+
+	var records []Record
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if !common.IsNilValue(err) {
+			return Document{}, fmt.Errorf("csv %s: %w", path, err)
+		}
+
+		rec := Record{Values: make(map[string]string, len(header))}
+		for i, v := range row {
+			key := "column_" + common.Itoa(i+1)
+			if i < len(header) {
+				key = header[i]
+			}
+			rec.Keys = append(rec.Keys, key)
+			rec.Values[key] = strings.TrimSpace(v)
+		}
+		if !rec.empty() {
+			records = append(records, rec)
+		}
+	}
+
+	return recordDocument(path, "text/csv", string(b), records), nil
+}
+
+// ParseJSON accepts an array of objects, an object wrapping one such array
+// (e.g. {"items": [...]}) or a single object, which becomes one record.
+func ParseJSON(path string) (Document, error) {
+	b, err := os.ReadFile(path)
+	if !common.IsNilValue(err) {
+		return Document{}, err
+	}
+
+	v, err := decodeOrdered(json.NewDecoder(bytes.NewReader(b)))
+	if !common.IsNilValue(err) {
+		return Document{}, fmt.Errorf("json %s: %w", path, err)
+	}
+
+	var items []any
+	switch t := v.(type) {
+	case []any:
+		items = t
+	case *orderedObject:
+		items = []any{t}
+		for _, k := range t.keys {
+			if list, ok := t.values[k].([]any); ok && len(list) > 0 {
+				if _, isObj := list[0].(*orderedObject); isObj {
+					items = list
+					break
+				}
+			}
+		}
+	default:
+		items = []any{v}
+	}
+
+	records := make([]Record, 0, len(items))
+	for _, item := range items {
+		if rec := toRecord(item); !rec.empty() {
+			records = append(records, rec)
+		}
+	}
+	return recordDocument(path, "application/json", string(b), records), nil
+}
+
+func ParseJSONL(path string) (Document, error) {
+	b, err := os.ReadFile(path)
+	if !common.IsNilValue(err) {
+		return Document{}, err
+	}
+
+	var records []Record
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		v, err := decodeOrdered(json.NewDecoder(strings.NewReader(text)))
+		if !common.IsNilValue(err) {
+			return Document{}, fmt.Errorf("jsonl %s line %d: %w", path, line, err)
+		}
+		if rec := toRecord(v); !rec.empty() {
+			records = append(records, rec)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return Document{}, fmt.Errorf("jsonl %s: %w", path, err)
+	}
+
+	return recordDocument(path, "application/x-ndjson", string(b), records), nil
+}
+
+func recordDocument(path, mime, content string, records []Record) Document {
+	return Document{
+		ID:      filepath.Base(path),
+		Path:    path,
+		MIME:    mime,
+		Content: content,
+		Records: records,
+	}
+}
+
+// ChunkRecords renders opts.PerChunk records per chunk, closing a group
+// early when the next record would take it past target tokens. A record
+// longer than target on its own is cut at sentence boundaries into chunks
+// "record-N#1", "record-N#2" and so on. Promoted fields hold a single
+// value, or the distinct values of a group when records differ. A target
+// of 0 leaves chunks uncapped.
+func ChunkRecords(doc Document, opts RecordOptions, tok tokenizer.Tokenizer, target int) ([]Chunk, error) {
+	per := max(1, opts.PerChunk)
+
+	var tmpl *template.Template
+	if strings.TrimSpace(opts.Template) != "" {
+		t, err := template.New("record").Option("missingkey=zero").Parse(opts.Template)
+		if !common.IsNilValue(err) {
+			return nil, fmt.Errorf("record template: %w", err)
+		}
+		tmpl = t
+	}
+
+	var (
+		out    []Chunk
+		texts  []string
+		tokens int
+		start  int
+	)
+	emit := func(end int) {
+		if len(texts) == 0 {
+			return
+		}
+		group := doc.Records[start:end]
+		id := "record-" + common.Itoa(start+1)
+		if len(group) > 1 {
+			id += "-" + common.Itoa(end)
+		}
+		fields := promote(group, opts.Fields)
+
+		text := strings.Join(texts, "\n\n")
+		if target > 0 && tokens > target {
+			for i, piece := range chunkSentences(doc.ID, text, 0, tok, target, 0) {
+				out = append(out, Chunk{
+					DocID:   doc.ID,
+					Index:   len(out),
+					Text:    piece.Text,
+					ChunkID: id + "#" + common.Itoa(i+1),
+					Fields:  fields,
+				})
+			}
+		} else {
+			out = append(out, Chunk{DocID: doc.ID, Index: len(out), Text: text, ChunkID: id, Fields: fields})
+		}
+		texts, tokens, start = nil, 0, end
+	}
+
+	for i, rec := range doc.Records {
+		text, err := rec.render(tmpl)
+		if !common.IsNilValue(err) {
+			return nil, fmt.Errorf("record %d: %w", i+1, err)
+		}
+		n := 0
+		if target > 0 {
+			n = tok.Count(text)
+		}
+		if len(texts) == per || target > 0 && len(texts) > 0 && tokens+n > target {
+			emit(i)
+		}
+		texts = append(texts, text)
+		tokens += n
+	}
+	emit(len(doc.Records))
+	return out, nil
+}
+
+func (r Record) empty() bool {
+	for _, v := range r.Values {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (r Record) render(tmpl *template.Template) (string, error) {
+	if tmpl == nil {
+		lines := make([]string, 0, len(r.Keys))
+		for _, k := range r.Keys {
+			if v := r.Values[k]; v != "" {
+				lines = append(lines, k+": "+v)
+			}
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, r.Values); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+func promote(group []Record, fields []string) map[string]any {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	out := make(map[string]any, len(fields))
+	for _, f := range fields {
+		seen := map[string]bool{}
+		var values []string
+		for _, rec := range group {
+			v, ok := rec.Values[f]
+			if !ok || v == "" || seen[v] {
+				continue
+			}
+			seen[v] = true
+			values = append(values, v)
+		}
+		// Qdrant reads dots in filter keys as nesting, so flattened keys
+		// are promoted as "user_name" rather than "user.name"
+		key := strings.ReplaceAll(f, ".", "_")
+		switch len(values) {
+		case 0:
+		case 1:
+			out[key] = scalar(values[0])
+		default:
+			list := make([]any, len(values))
+			for i, v := range values {
+				list[i] = scalar(v)
+			}
+			out[key] = list
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// number matches the canonical decimal forms scalar converts. Values with a
+// leading zero, a sign, hex digits or spelled out infinities stay strings,
+// which keeps IDs and zip codes intact and JSON payloads encodable.
+var number = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// scalar keeps numbers and booleans typed so range and match filters work.
+func scalar(s string) any {
+	if s == "true" || s == "false" {
+		return s == "true"
+	}
+	if !number.MatchString(s) {
+		return s
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return f
+	}
+	return s
+}
+
+// orderedObject keeps JSON object keys in document order, which plain
+// map decoding loses and which matters for the default record rendering.
+type orderedObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func decodeOrdered(dec *json.Decoder) (any, error) {
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	return decodeValue(dec, tok)
+}
+
+func decodeValue(dec *json.Decoder, tok json.Token) (any, error) {
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			obj := &orderedObject{values: map[string]any{}}
+			for dec.More() {
+				kt, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, _ := kt.(string)
+				vt, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				v, err := decodeValue(dec, vt)
+				if err != nil {
+					return nil, err
+				}
+				if _, dup := obj.values[key]; !dup {
+					obj.keys = append(obj.keys, key)
+				}
+				obj.values[key] = v
+			}
+			_, err := dec.Token()
+			return obj, err
+		case '[':
+			var list []any
+			for dec.More() {
+				vt, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				v, err := decodeValue(dec, vt)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			_, err := dec.Token()
+			return list, err
+		}
+		return nil, fmt.Errorf("unexpected %v", t)
+	default:
+		return tok, nil
+	}
+}
+
+func toRecord(v any) Record {
+	rec := Record{Values: map[string]string{}}
+	if obj, ok := v.(*orderedObject); ok {
+		flatten(&rec, "", obj)
+	} else {
+		rec.Keys = []string{"value"}
+		rec.Values["value"] = stringify(v)
+	}
+	return rec
+}
+
+func flatten(rec *Record, prefix string, obj *orderedObject) {
+	for _, k := range obj.keys {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := obj.values[k].(*orderedObject); ok {
+			flatten(rec, key, nested)
+			continue
+		}
+		rec.Keys = append(rec.Keys, key)
+		rec.Values[key] = stringify(obj.values[k])
+	}
+}
+
+func stringify(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	case bool:
+		return strconv.FormatBool(t)
+	case []any:
+		parts := make([]string, 0, len(t))
+		for _, item := range t {
+			if s := stringify(item); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ", ")
+	case *orderedObject:
+		parts := make([]string, 0, len(t.keys))
+		for _, k := range t.keys {
+			parts = append(parts, k+"="+stringify(t.values[k]))
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	default:
+		return fmt.Sprint(t)
+	}
+}
@@ -0,0 +1,127 @@
+package docs
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/brunomgama/go_rag/internal/tokenizer"
+)
+
+func TestScalar(t *testing.T) {
+	tests := []struct {
+		in   string
+		want any
+	}{
+		{"42", int64(42)},
+		{"-7", int64(-7)},
+		{"0", int64(0)},
+		{"12.5", 12.5},
+		{"-0.25", -0.25},
+		{"1e3", 1000.0},
+		{"99999999999999999999", 1e20},
+		{"true", true},
+		{"false", false},
+		{"0123", "0123"},
+		{"00501", "00501"},
+		{"+5", "+5"},
+		{".5", ".5"},
+		{"5.", "5."},
+		{"0x1F", "0x1F"},
+		{"1_000", "1_000"},
+		{"nan", "nan"},
+		{"NaN", "NaN"},
+		{"inf", "inf"},
+		{"-Infinity", "-Infinity"},
+		{"1e999", "1e999"},
+		{"True", "True"},
+		{"", ""},
+		{"12 apples", "12 apples"},
+	}
+	for _, tt := range tests {
+		if got := scalar(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("scalar(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPromoteEncodes(t *testing.T) {
+	group := []Record{
+		{Keys: []string{"score", "zip"}, Values: map[string]string{"score": "nan", "zip": "0123"}},
+		{Keys: []string{"score", "zip"}, Values: map[string]string{"score": "inf", "zip": "0456"}},
+	}
+	fields := promote(group, []string{"score", "zip"})
+	if _, err := json.Marshal(fields); err != nil {
+		t.Fatalf("promoted fields do not encode: %v", err)
+	}
+	if want := []any{"0123", "0456"}; !reflect.DeepEqual(fields["zip"], want) {
+		t.Errorf("zip = %#v, want %#v", fields["zip"], want)
+	}
+}
+
+func TestChunkRecords(t *testing.T) {
+	rec := func(q string) Record {
+		return Record{Keys: []string{"q"}, Values: map[string]string{"q": q}}
+	}
+	long := words("w", 20)
+	doc := Document{ID: "faq.csv", Records: []Record{rec("a b"), rec("c d"), rec(long), rec("e")}}
+
+	// uncapped, records are only grouped by count
+	chunks, err := ChunkRecords(doc, RecordOptions{PerChunk: 3}, tokenizer.Words{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := chunkIDs(chunks); !reflect.DeepEqual(got, []string{"record-1-3", "record-4"}) {
+		t.Errorf("uncapped chunks = %q", got)
+	}
+
+	// "q: a b" and "q: c d" fit 8 words together, the long record is cut
+	chunks, err = ChunkRecords(doc, RecordOptions{PerChunk: 3, Fields: []string{"q"}}, tokenizer.Words{}, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := chunkIDs(chunks)
+	want := []string{"record-1-2", "record-3#1", "record-3#2", "record-3#3", "record-4"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Fatalf("capped chunks = %q, want %q", ids, want)
+	}
+	var pieces []string
+	for i, c := range chunks {
+		if c.Index != i {
+			t.Errorf("chunk %s has index %d, want %d", c.ChunkID, c.Index, i)
+		}
+		if n := len(strings.Fields(c.Text)); n > 8 {
+			t.Errorf("chunk %s has %d words, over the target", c.ChunkID, n)
+		}
+		if strings.HasPrefix(c.ChunkID, "record-3#") {
+			pieces = append(pieces, c.Text)
+			if c.Fields["q"] != long {
+				t.Errorf("piece %s fields = %v", c.ChunkID, c.Fields)
+			}
+		}
+	}
+	if got := strings.Join(pieces, " "); got != "q: "+long {
+		t.Errorf("pieces of the long record rebuild %q", got)
+	}
+	if chunks[0].Text != "q: a b\n\nq: c d" {
+		t.Errorf("first chunk = %q", chunks[0].Text)
+	}
+}
+
+func words(prefix string, n int) string {
+	w := make([]string, n)
+	for i := range w {
+		w[i] = prefix + strconv.Itoa(i)
+	}
+	return strings.Join(w, " ")
+}
+
+func chunkIDs(chunks []Chunk) []string {
+	out := make([]string, len(chunks))
+	for i, c := range chunks {
+		out[i] = c.ChunkID
+	}
+	return out
+}
@@ -0,0 +1,48 @@
+package docs
+
+import "strings"
+
+// sectionBuilder accumulates body text under a stack of headings and cuts a
+// new Section whenever a heading opens.
+type sectionBuilder struct {
+	out   []Section
+	stack []string
+	body  []string
+}
+
+func (b *sectionBuilder) heading(level int, title string) {
+	b.flush()
+	if level-1 < len(b.stack) {
+		b.stack = b.stack[:level-1]
+	}
+	for len(b.stack) < level-1 {
+		b.stack = append(b.stack, "")
+	}
+	b.stack = append(b.stack, title)
+	b.body = append(b.body, title)
+}
+
+func (b *sectionBuilder) line(s string) {
+	b.body = append(b.body, s)
+}
+
+func (b *sectionBuilder) flush() {
+	text := strings.TrimSpace(strings.Join(b.body, "\n"))
+	if text != "" {
+		b.out = append(b.out, Section{Heading: append([]string(nil), b.stack...), Text: text})
+	}
+	b.body = nil
+}
+
+func (b *sectionBuilder) sections() []Section {
+	b.flush()
+	return b.out
+}
+
+func joinSections(sections []Section) string {
+	texts := make([]string, len(sections))
+	for i, s := range sections {
+		texts[i] = s.Text
+	}
+	return strings.Join(texts, "\n\n")
+}
@@ -0,0 +1,135 @@
+package docs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/brunomgama/go_rag/internal/common"
+	"github.com/brunomgama/go_rag/internal/embed"
+	"github.com/brunomgama/go_rag/internal/tokenizer"
+)
+
+// SemanticOptions tune SemanticSplitter. Percentile picks the similarity
+// threshold among all adjacent sentence pairs of a text: with 10, roughly
+// the 10% least similar pairs become candidate boundaries. Chunks are not
+// cut before MinTokens and always before MaxTokens.
+type SemanticOptions struct {
+	Percentile float64
+	MinTokens  int
+	MaxTokens  int
+	BatchSize  int
+}
+
+// SemanticSplitter embeds every sentence and starts a new chunk where the
+// similarity between neighbouring sentences drops below the configured
+// percentile, so each chunk stays on one topic. It costs one extra
+// embedding per sentence on top of the chunk embeddings.
+func SemanticSplitter(ctx context.Context, emb embed.Embedder, tok tokenizer.Tokenizer, opts SemanticOptions) Splitter {
+	return func(docID, text string, page int) ([]Chunk, error) {
+		chunks, err := chunkSemantic(ctx, docID, text, page, emb, tok, opts)
+		if !common.IsNilValue(err) {
+			return nil, fmt.Errorf("semantic chunk %s: %w", docID, err)
+		}
+		return chunks, nil
+	}
+}
+
+func chunkSemantic(ctx context.Context, docID, text string, page int, emb embed.Embedder, tok tokenizer.Tokenizer, opts SemanticOptions) ([]Chunk, error) {
+	maxTokens := max(1, opts.MaxTokens)
+	minTokens := min(max(0, opts.MinTokens), maxTokens)
+
+	var (
+		units  []span
+		counts []int
+	)
+	for _, s := range sentenceSpans(text) {
+		pieces := []span{s}
+		if tok.Count(text[s.start:s.end]) > maxTokens {
+			pieces = splitWords(text, s, tok, maxTokens)
+		}
+		for _, p := range pieces {
+			units = append(units, p)
+			counts = append(counts, tok.Count(text[p.start:p.end]))
+		}
+	}
+	if len(units) == 0 {
+		return nil, nil
+	}
+
+	sims, err := adjacentSimilarity(ctx, text, units, emb, opts.BatchSize)
+	if !common.IsNilValue(err) {
+		return nil, err
+	}
+	threshold := percentile(sims, opts.Percentile)
+
+	var chunks []Chunk
+	start, tokens := 0, counts[0]
+	for i := 1; i <= len(units); i++ {
+		cut := i == len(units) ||
+			tokens+counts[i] > maxTokens ||
+			(tokens >= minTokens && sims[i-1] < threshold)
+		if !cut {
+			tokens += counts[i]
+			continue
+		}
+
+		src := span{units[start].start, units[i-1].end}
+		chunks = append(chunks, newChunk(docID, page, len(chunks), text[src.start:src.end], src))
+		if i < len(units) {
+			start, tokens = i, counts[i]
+		}
+	}
+	return chunks, nil
+}
+
+// adjacentSimilarity returns the cosine similarity between each unit and
+// the next, embedding the units in batches of size.
+func adjacentSimilarity(ctx context.Context, text string, units []span, emb embed.Embedder, size int) ([]float32, error) {
+	if size <= 0 {
+		size = 64
+	}
+
+	vecs := make([][]float32, 0, len(units))
+	for i := 0; i < len(units); i += size {
+		batch := units[i:min(i+size, len(units))]
+		texts := make([]string, len(batch))
+		for j, u := range batch {
+			texts[j] = text[u.start:u.end]
+		}
+
+		got, err := emb.Embed(ctx, texts)
+		if !common.IsNilValue(err) {
+			return nil, err
+		}
+		if len(got) != len(texts) {
+			return nil, fmt.Errorf("got %d vectors for %d sentences", len(got), len(texts))
+		}
+		vecs = append(vecs, got...)
+	}
+
+	sims := make([]float32, len(vecs)-1)
+	for i := range sims {
+		sims[i] = common.Cosine(vecs[i], vecs[i+1])
+	}
+	return sims, nil
+}
+
+// percentile returns the p-th percentile of values by linear interpolation
+// between the closest ranks.
+func percentile(values []float32, p float64) float32 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float32(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	p = min(max(p, 0), 100)
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(rank)
+	if lo+1 >= len(sorted) {
+		return sorted[lo]
+	}
+	frac := float32(rank - float64(lo))
+	return sorted[lo] + frac*(sorted[lo+1]-sorted[lo])
+}
@@ -0,0 +1,158 @@
+package docs
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/brunomgama/go_rag/internal/tokenizer"
+)
+
+// topicEmbedder gives every text a vector counting its topic words, so
+// sentences on the same topic have similarity 1 and others 0. It records
+// the size of each batch.
+type topicEmbedder struct {
+	batches []int
+	short   bool
+	err     error
+}
+
+var semanticTopics = []string{"dice", "money", "jail"}
+
+func (e *topicEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.batches = append(e.batches, len(texts))
+	if e.err != nil {
+		return nil, e.err
+	}
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec := make([]float32, len(semanticTopics))
+		for j, topic := range semanticTopics {
+			vec[j] = float32(strings.Count(strings.ToLower(text), topic))
+		}
+		out[i] = vec
+	}
+	if e.short {
+		out = out[1:]
+	}
+	return out, nil
+}
+
+const topicText = "Dice roll here. Dice again now. Money is paid. Money is earned. Jail waits."
+
+func semanticChunks(t *testing.T, emb *topicEmbedder, text string, opts SemanticOptions) []string {
+	t.Helper()
+	chunks, err := SemanticSplitter(context.Background(), emb, tokenizer.Words{}, opts)("a.txt", text, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range chunks {
+		if text[c.src.start:c.src.end] != c.Text {
+			t.Errorf("chunk %s src %v does not cut %q", c.ChunkID, c.src, c.Text)
+		}
+	}
+	return chunkTexts(chunks)
+}
+
+func TestSemanticSplitterTopics(t *testing.T) {
+	// similarities are 1, 0, 1, 0; the median threshold of 0.5 cuts at the drops
+	got := semanticChunks(t, &topicEmbedder{}, topicText, SemanticOptions{Percentile: 50, MaxTokens: 100})
+	want := []string{"Dice roll here. Dice again now.", "Money is paid. Money is earned.", "Jail waits."}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("chunks =\n%q\nwant\n%q", got, want)
+	}
+
+	// no pair is less similar than the lowest one
+	got = semanticChunks(t, &topicEmbedder{}, topicText, SemanticOptions{Percentile: 0, MaxTokens: 100})
+	if !reflect.DeepEqual(got, []string{topicText}) {
+		t.Errorf("percentile 0 chunks = %q, want the whole text", got)
+	}
+}
+
+func TestSemanticSplitterMinTokens(t *testing.T) {
+	// the drop after six words comes before seven are collected
+	got := semanticChunks(t, &topicEmbedder{}, topicText, SemanticOptions{Percentile: 50, MinTokens: 7, MaxTokens: 100})
+	want := []string{"Dice roll here. Dice again now. Money is paid. Money is earned.", "Jail waits."}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("chunks =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestSemanticSplitterMaxTokens(t *testing.T) {
+	text := "Dice roll here. Dice again now. Dice " + words("d", 10) + "."
+	got := semanticChunks(t, &topicEmbedder{}, text, SemanticOptions{Percentile: 50, MinTokens: 50, MaxTokens: 6})
+	for _, c := range got {
+		if n := len(strings.Fields(c)); n > 6 {
+			t.Errorf("chunk %q has %d words, over MaxTokens", c, n)
+		}
+	}
+	if got[0] != "Dice roll here. Dice again now." {
+		t.Errorf("first chunk = %q, want the two short sentences", got[0])
+	}
+	if strings.Join(got, " ") != text {
+		t.Errorf("chunks %q do not rebuild the text", got)
+	}
+}
+
+func TestSemanticSplitterBatches(t *testing.T) {
+	emb := &topicEmbedder{}
+	semanticChunks(t, emb, topicText, SemanticOptions{Percentile: 50, MaxTokens: 100, BatchSize: 2})
+	if !reflect.DeepEqual(emb.batches, []int{2, 2, 1}) {
+		t.Errorf("batches = %v, want 2, 2, 1", emb.batches)
+	}
+
+	emb = &topicEmbedder{}
+	semanticChunks(t, emb, topicText, SemanticOptions{Percentile: 50, MaxTokens: 100})
+	if !reflect.DeepEqual(emb.batches, []int{5}) {
+		t.Errorf("default batches = %v, want one of 5", emb.batches)
+	}
+
+	emb = &topicEmbedder{}
+	if got := semanticChunks(t, emb, "  \n ", SemanticOptions{MaxTokens: 100}); len(got) != 0 || len(emb.batches) != 0 {
+		t.Errorf("blank text gave %q after %d embed calls", got, len(emb.batches))
+	}
+}
+
+func TestSemanticSplitterErrors(t *testing.T) {
+	down := errors.New("embedding backend down")
+	split := SemanticSplitter(context.Background(), &topicEmbedder{err: down}, tokenizer.Words{}, SemanticOptions{MaxTokens: 100})
+	if _, err := split("a.txt", topicText, 0); !errors.Is(err, down) || !strings.Contains(err.Error(), "semantic chunk a.txt") {
+		t.Errorf("err = %v", err)
+	}
+
+	split = SemanticSplitter(context.Background(), &topicEmbedder{short: true}, tokenizer.Words{}, SemanticOptions{MaxTokens: 100})
+	if _, err := split("a.txt", topicText, 0); err == nil || !strings.Contains(err.Error(), "got 4 vectors for 5 sentences") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float32{0.9, 0.1, 0.5, 0.3}
+	tests := []struct {
+		p    float64
+		want float32
+	}{
+		{0, 0.1},
+		{100, 0.9},
+		{50, 0.4},
+		{-5, 0.1},
+		{250, 0.9},
+		{25, 0.25},
+	}
+	for _, tt := range tests {
+		if got := percentile(values, tt.p); got < tt.want-1e-6 || got > tt.want+1e-6 {
+			t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if !reflect.DeepEqual(values, []float32{0.9, 0.1, 0.5, 0.3}) {
+		t.Errorf("percentile sorted its input: %v", values)
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile of nothing = %v", got)
+	}
+	if got := percentile([]float32{0.7}, 50); got != 0.7 {
+		t.Errorf("percentile of one value = %v", got)
+	}
+}
@@ -0,0 +1,201 @@
+package docs
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/brunomgama/go_rag/internal/tokenizer"
+)
+
+// abbreviations end in a period without ending the sentence. Keys are
+// lower case and without the final period.
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true, "sr": true,
+	"jr": true, "st": true, "vs": true, "e.g": true, "i.e": true, "cf": true,
+	"al": true, "inc": true, "ltd": true, "co": true, "corp": true, "fig": true,
+	"figs": true, "no": true, "vol": true, "p": true, "pp": true, "ch": true,
+	"sec": true, "approx": true, "dept": true, "est": true, "ca": true,
+	"jan": true, "feb": true, "mar": true, "apr": true, "jun": true, "jul": true,
+	"aug": true, "sep": true, "sept": true, "oct": true, "nov": true, "dec": true,
+}
+
+// listItem matches the start of a bulleted or enumerated line.
+var listItem = regexp.MustCompile(`^[ \t]*([-*+•]|\d+[.)]|[A-Za-z][.)])[ \t]+\S`)
+
+const (
+	terminators = ".!?…"
+	closers     = "\"')]}”’»"
+)
+
+// SentenceSplitter packs whole sentences into chunks of at most target
+// tokens, repeating whole sentences worth up to overlap tokens between
+// neighbours. Chunk text is cut from the original so paragraph breaks and
+// list layout survive; sentences longer than target are split between words.
+func SentenceSplitter(tok tokenizer.Tokenizer, target, overlap int) Splitter {
+	return func(docID, text string, page int) ([]Chunk, error) {
+		return chunkSentences(docID, text, page, tok, target, overlap), nil
+	}
+}
+
+func chunkSentences(docID, text string, page int, tok tokenizer.Tokenizer, target, overlap int) []Chunk {
+	target = max(1, target)
+
+	var (
+		units  []span
+		counts []int
+	)
+	for _, s := range sentenceSpans(text) {
+		if n := tok.Count(text[s.start:s.end]); n <= target {
+			units = append(units, s)
+			counts = append(counts, n)
+			continue
+		}
+		for _, piece := range splitWords(text, s, tok, target) {
+			units = append(units, piece)
+			counts = append(counts, tok.Count(text[piece.start:piece.end]))
+		}
+	}
+
+	var chunks []Chunk
+	for start := 0; start < len(units); {
+		end, tokens := start, 0
+		for end < len(units) && (end == start || tokens+counts[end] <= target) {
+			tokens += counts[end]
+			end++
+		}
+
+		src := span{units[start].start, units[end-1].end}
+		chunks = append(chunks, newChunk(docID, page, len(chunks), text[src.start:src.end], src))
+		if end == len(units) {
+			break
+		}
+		start = overlapStart(counts, start, end, target, overlap)
+	}
+	return chunks
+}
+
+// sentenceSpans returns the byte ranges of the sentences in text, trimmed of
+// surrounding whitespace. Blank lines and list items always start a new
+// sentence. A terminator only ends one when followed by whitespace and not
+// by a lower case word, and a period does not when it closes an
+// abbreviation, an initial or a list number.
+func sentenceSpans(text string) []span {
+	var out []span
+	start := -1
+	emit := func(end int) {
+		for end > start && isSpaceByte(text[end-1]) {
+			end--
+		}
+		if end > start {
+			out = append(out, span{start, end})
+		}
+		start = -1
+	}
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if start < 0 {
+			if !unicode.IsSpace(r) {
+				start = i
+			}
+			i += size
+			continue
+		}
+
+		switch {
+		case r == '\n':
+			j := i + 1
+			for j < len(text) && (text[j] == ' ' || text[j] == '\t' || text[j] == '\r') {
+				j++
+			}
+			if j < len(text) && text[j] == '\n' || listItem.MatchString(text[i+1:]) {
+				emit(i)
+			}
+		case strings.ContainsRune(terminators, r):
+			end := i + size
+			for end < len(text) {
+				c, n := utf8.DecodeRuneInString(text[end:])
+				if !strings.ContainsRune(terminators, c) && !strings.ContainsRune(closers, c) {
+					break
+				}
+				end += n
+			}
+			if sentenceEnd(text, start, i, end, r) {
+				emit(end)
+			}
+			i = end
+			continue
+		}
+		i += size
+	}
+	if start >= 0 {
+		emit(len(text))
+	}
+	return out
+}
+
+// sentenceEnd decides whether the terminator r at text[at] ending a run at
+// end closes the sentence that began at start.
+func sentenceEnd(text string, start, at, end int, r rune) bool {
+	if end < len(text) {
+		c, _ := utf8.DecodeRuneInString(text[end:])
+		if !unicode.IsSpace(c) {
+			return false // decimals, urls, "e.g.x"
+		}
+		rest := strings.TrimLeftFunc(text[end:], unicode.IsSpace)
+		if c, _ := utf8.DecodeRuneInString(rest); unicode.IsLower(c) {
+			return false
+		}
+	}
+	if r != '.' || end != at+1 {
+		return true
+	}
+
+	wordStart := strings.LastIndexFunc(text[start:at], unicode.IsSpace) + 1 + start
+	word := strings.TrimLeft(text[wordStart:at], "(\"'“‘")
+	switch {
+	case abbreviations[strings.ToLower(word)]:
+		return false
+	case utf8.RuneCountInString(word) == 1 && unicode.IsUpper([]rune(word)[0]):
+		return false // initial
+	case wordStart == start && isDigits(word):
+		return false // "1." opening a list item
+	}
+	return true
+}
+
+// splitWords cuts s into pieces of at most target tokens between words,
+// cutting inside a word only when the word alone is too long.
+func splitWords(text string, s span, tok tokenizer.Tokenizer, target int) []span {
+	words, counts := tokenWords(text, wordSpans(text, s), tok, target)
+
+	var out []span
+	for start := 0; start < len(words); {
+		end, tokens := start, 0
+		for end < len(words) && (end == start || tokens+counts[end] <= target) {
+			tokens += counts[end]
+			end++
+		}
+		out = append(out, span{words[start].start, words[end-1].end})
+		start = end
+	}
+	return out
+}
+
+func isSpaceByte(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f' || b == '\v'
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package docs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/brunomgama/go_rag/internal/tokenizer"
+)
+
+func sentences(text string) []string {
+	var out []string
+	for _, s := range sentenceSpans(text) {
+		out = append(out, text[s.start:s.end])
+	}
+	return out
+}
+
+func TestSentenceSpans(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"plain", "One here. Two there! Three? Four",
+			[]string{"One here.", "Two there!", "Three?", "Four"}},
+		{"abbreviations", "Ask Dr. Smith, e.g. about Fig. 3. Then leave.",
+			[]string{"Ask Dr. Smith, e.g. about Fig. 3.", "Then leave."}},
+		{"abbreviation in parentheses", "See (cf. Jones) for more. Done.",
+			[]string{"See (cf. Jones) for more.", "Done."}},
+		{"initials", "Written by J. R. R. Tolkien. Next.",
+			[]string{"Written by J. R. R. Tolkien.", "Next."}},
+		{"decimals", "Pay 3.50 each. Or 1.5e3 in total.",
+			[]string{"Pay 3.50 each.", "Or 1.5e3 in total."}},
+		{"urls", "Visit example.com/a.b today. Bye.",
+			[]string{"Visit example.com/a.b today.", "Bye."}},
+		{"lower case continuation", "It costs approx. ten. it is cheap. Yes.",
+			[]string{"It costs approx. ten. it is cheap.", "Yes."}},
+		{"closers", `He said "Stop." Then (quietly.) Left.`,
+			[]string{`He said "Stop."`, "Then (quietly.)", "Left."}},
+		{"repeated terminators", "Really?! Yes... Fine.",
+			[]string{"Really?!", "Yes...", "Fine."}},
+		{"ellipsis rune", "Wait… Go on.",
+			[]string{"Wait…", "Go on."}},
+		{"blank line", "Heading without stop\n\nBody text here.",
+			[]string{"Heading without stop", "Body text here."}},
+		{"single newline joins", "A sentence that\nwraps a line.",
+			[]string{"A sentence that\nwraps a line."}},
+		{"bullets", "Items:\n- first item\n* second item\n• third",
+			[]string{"Items:", "- first item", "* second item", "• third"}},
+		{"numbered list", "Steps\n1. Open the box.\n2. Read it\n3) Play",
+			[]string{"Steps", "1. Open the box.", "2. Read it", "3) Play"}},
+		{"lettered list", "Options\na) red\nb. blue",
+			[]string{"Options", "a) red", "b. blue"}},
+		{"number ending a sentence", "There are 3. Then more.",
+			[]string{"There are 3.", "Then more."}},
+		{"surrounding space", "  \n Lead. \n\n ",
+			[]string{"Lead."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sentences(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sentences =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSentenceSplitterKeepsLayout(t *testing.T) {
+	text := "Setup\n\n- Place the board.\n- Shuffle the cards.\n\nPlay starts with the youngest player."
+	chunks, err := SentenceSplitter(tokenizer.Words{}, 100, 0)("a.md", text, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 1 || chunks[0].Text != text {
+		t.Errorf("chunks = %q, want the text unchanged", chunkTexts(chunks))
+	}
+}
+
+func TestSentenceSplitterOverlap(t *testing.T) {
+	// five sentences of three words each
+	text := "One two three. Four five six. Seven eight nine. Ten eleven twelve. Last one here."
+	chunks, err := SentenceSplitter(tokenizer.Words{}, 7, 3)("a.txt", text, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"One two three. Four five six.",
+		"Four five six. Seven eight nine.",
+		"Seven eight nine. Ten eleven twelve.",
+		"Ten eleven twelve. Last one here.",
+	}
+	if got := chunkTexts(chunks); !reflect.DeepEqual(got, want) {
+		t.Errorf("chunks =\n%q\nwant\n%q", got, want)
+	}
+	for i, c := range chunks {
+		if text[c.src.start:c.src.end] != c.Text {
+			t.Errorf("chunk %d src %v does not cut %q", i, c.src, c.Text)
+		}
+	}
+}
+
+func TestSentenceSplitterLongSentence(t *testing.T) {
+	text := "Short one. " + strings.Repeat("word ", 12) + "end."
+	chunks, err := SentenceSplitter(tokenizer.Words{}, 5, 0)("a.txt", text, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := chunkTexts(chunks)
+	for _, c := range got {
+		if n := len(strings.Fields(c)); n > 5 {
+			t.Errorf("chunk %q has %d words, want at most 5", c, n)
+		}
+	}
+	if strings.Join(got, " ") != text {
+		t.Errorf("chunks %q do not rebuild the text", got)
+	}
+}
+
+func chunkTexts(chunks []Chunk) []string {
+	out := make([]string, len(chunks))
+	for i, c := range chunks {
+		out[i] = c.Text
+	}
+	return out
+}
@@ -0,0 +1,23 @@
+package embed
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brunomgama/go_rag/internal/config"
+)
+
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+func FromConfig(cfg config.Config) (Embedder, error) {
+	switch cfg.EmbeddingsProvider {
+	case "", "ollama":
+		return NewOllama(cfg.OllamaHost, cfg.EmbeddingsModel), nil
+	case "openai":
+		return NewOpenAI(cfg.OpenAIBaseURL, cfg.OpenAIAPIKey, cfg.EmbeddingsModel), nil
+	default:
+		return nil, fmt.Errorf("unknown embeddings provider %q", cfg.EmbeddingsProvider)
+	}
+}
@@ -0,0 +1,96 @@
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brunomgama/go_rag/internal/common"
+)
+
+// OpenAI speaks the /v1/embeddings wire format, which is also served by
+// vLLM, LM Studio, llama.cpp server and most other self-hosted runtimes.
+type OpenAI struct {
+	baseURL string
+	apiKey  string
+	model   string
+	http    *http.Client
+}
+
+type openAIRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type openAIResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func NewOpenAI(baseURL, apiKey, model string) *OpenAI {
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+
+	return &OpenAI{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		http:    &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (c *OpenAI) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	body, _ := json.Marshal(openAIRequest{Model: c.model, Input: texts})
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/embeddings", bytes.NewReader(body))
+	if !common.IsNilValue(err) {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	res, err := c.http.Do(req)
+	if !common.IsNilValue(err) {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	raw, _ := io.ReadAll(res.Body)
+	var out openAIResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("openai embeddings status %d: %s", res.StatusCode, truncate(raw, 300))
+	}
+	if out.Error != nil {
+		return nil, fmt.Errorf("openai embeddings status %d: %s", res.StatusCode, out.Error.Message)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("openai embeddings status %d: %s", res.StatusCode, truncate(raw, 300))
+	}
+	if len(out.Data) != len(texts) {
+		return nil, fmt.Errorf("openai embeddings: got %d vectors for %d inputs", len(out.Data), len(texts))
+	}
+
+	sort.Slice(out.Data, func(i, j int) bool { return out.Data[i].Index < out.Data[j].Index })
+	vectors := make([][]float32, len(out.Data))
+	for i, d := range out.Data {
+		vectors[i] = d.Embedding
+	}
+	return vectors, nil
+}
@@ -0,0 +1,88 @@
+package embed
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// stubOpenAI serves /v1/embeddings with the given status and body, and
+// records the last request it got.
+func stubOpenAI(t *testing.T, status int, body string) (*OpenAI, *openAIRequest, *http.Header) {
+	t.Helper()
+	var (
+		got    openAIRequest
+		header http.Header
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/embeddings" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		header = r.Header.Clone()
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return NewOpenAI(srv.URL+"/v1/", "secret", "test-model"), &got, &header
+}
+
+func TestOpenAIEmbedOrdersByIndex(t *testing.T) {
+	c, req, header := stubOpenAI(t, http.StatusOK, `{"data":[
+		{"index":2,"embedding":[3,3]},
+		{"index":0,"embedding":[1,1]},
+		{"index":1,"embedding":[2,2]}]}`)
+
+	got, err := c.Embed(context.Background(), []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]float32{{1, 1}, {2, 2}, {3, 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("vectors = %v, want %v", got, want)
+	}
+	if req.Model != "test-model" || !reflect.DeepEqual(req.Input, []string{"a", "b", "c"}) {
+		t.Errorf("request = %+v", *req)
+	}
+	if auth := header.Get("Authorization"); auth != "Bearer secret" {
+		t.Errorf("Authorization = %q", auth)
+	}
+}
+
+func TestOpenAIEmbedErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"error body", http.StatusUnauthorized, `{"error":{"message":"invalid api key"}}`, "status 401: invalid api key"},
+		{"error body with 200", http.StatusOK, `{"error":{"message":"model not loaded"}}`, "model not loaded"},
+		{"non-json status", http.StatusBadGateway, `<html>bad gateway</html>`, "status 502: <html>bad gateway</html>"},
+		{"non-200 without error", http.StatusServiceUnavailable, `{"data":[]}`, "status 503"},
+		{"count mismatch", http.StatusOK, `{"data":[{"index":0,"embedding":[1]}]}`, "got 1 vectors for 2 inputs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, _ := stubOpenAI(t, tt.status, tt.body)
+			_, err := c.Embed(context.Background(), []string{"a", "b"})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIEmbedEmpty(t *testing.T) {
+	c := NewOpenAI("http://127.0.0.1:0", "", "m")
+	got, err := c.Embed(context.Background(), nil)
+	if err != nil || got != nil {
+		t.Errorf("Embed(nil) = %v, %v; want no request", got, err)
+	}
+}
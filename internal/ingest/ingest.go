@@ -0,0 +1,432 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brunomgama/go_rag/internal/common"
+	"github.com/brunomgama/go_rag/internal/config"
+	"github.com/brunomgama/go_rag/internal/docs"
+	"github.com/brunomgama/go_rag/internal/embed"
+	"github.com/brunomgama/go_rag/internal/store"
+	"github.com/brunomgama/go_rag/internal/tokenizer"
+)
+
+// Pipeline parses, chunks, embeds and upserts documents. It is shared by
+// cmd/ingest and the API upload endpoint and is safe for concurrent use.
+type Pipeline struct {
+	Embed        embed.Embedder
+	Store        store.VectorStore
+	ChunkTarget  int
+	ChunkOverlap int
+	Strategy     string
+	Semantic     docs.SemanticOptions
+	ParentTokens int
+	Tokenizer    tokenizer.Tokenizer
+	BatchSize    int
+	Workers      Workers
+	Records      docs.RecordOptions
+	Parse        docs.ParseOptions
+	Archive      docs.ArchiveLimits
+	// Root, when set, names documents by their path below it, so files
+	// sharing a base name in different directories stay apart.
+	Root string
+
+	mu  sync.Mutex
+	dim int
+}
+
+// NewPipeline returns a Pipeline writing to st with the chunking, batching,
+// worker and limit settings of cfg.
+func NewPipeline(cfg config.Config, emb embed.Embedder, st store.VectorStore, tok tokenizer.Tokenizer) *Pipeline {
+	return &Pipeline{
+		Embed:        emb,
+		Store:        st,
+		ChunkTarget:  cfg.ChunkTarget,
+		ChunkOverlap: cfg.ChunkOverlap,
+		Strategy:     cfg.ChunkStrategy,
+		ParentTokens: cfg.ParentTokens,
+		Tokenizer:    tok,
+		BatchSize:    cfg.IngestBatchSize,
+		Workers: Workers{
+			Parse:  cfg.ParseWorkers,
+			Chunk:  cfg.ChunkWorkers,
+			Embed:  cfg.EmbedWorkers,
+			Upsert: cfg.UpsertWorkers,
+		},
+		Semantic: docs.SemanticOptions{
+			Percentile: cfg.SemanticPercentile,
+			MinTokens:  cfg.SemanticMinTokens,
+		},
+		Records: docs.RecordOptions{
+			PerChunk: cfg.RecordsPerChunk,
+			Template: cfg.RecordTemplate,
+			Fields:   cfg.RecordFields,
+		},
+		Parse: docs.ParseOptions{MaxPDFPages: cfg.PDFMaxPages},
+		Archive: docs.ArchiveLimits{
+			MaxMembers:    cfg.ArchiveMaxMembers,
+			MaxTotalBytes: cfg.ArchiveMaxBytes,
+		},
+	}
+}
+
+type Result struct {
+	DocID      string        `json:"doc_id"`
+	Chunks     int           `json:"chunks"`
+	Vectors    int           `json:"vectors"`
+	Tokens     int           `json:"-"`
+	EmptyPages int           `json:"empty_pages,omitempty"`
+	Warnings   []Warning     `json:"warnings,omitempty"`
+	ParseChunk time.Duration `json:"-"`
+	Embed      time.Duration `json:"-"`
+	Upsert     time.Duration `json:"-"`
+	// PointKeys are the keys of the upserted points, so callers replacing
+	// an earlier revision can drop the points it left behind.
+	PointKeys []string `json:"-"`
+}
+
+// Warning is a page level parse problem of one of the documents of a file.
+type Warning struct {
+	DocID string `json:"doc_id"`
+	docs.PageWarning
+}
+
+// ParseError reports a file that could not be parsed. Callers usually skip
+// such files, while embedding and upsert failures abort the run.
+type ParseError struct {
+	Path string
+	Err  error
+}
+
+func (e *ParseError) Error() string { return fmt.Sprintf("parse error %s: %v", e.Path, e.Err) }
+func (e *ParseError) Unwrap() error { return e.Err }
+
+func (p *Pipeline) IngestFile(ctx context.Context, path string) (Result, error) {
+	t0 := time.Now()
+	parsed, id, err := p.parse(path)
+	if !common.IsNilValue(err) {
+		return Result{}, err
+	}
+	elapsed := time.Since(t0)
+
+	res, err := p.ingest(ctx, id, parsed)
+	res.ParseChunk += elapsed
+	return res, err
+}
+
+func (p *Pipeline) IngestDocument(ctx context.Context, doc docs.Document) (Result, error) {
+	return p.ingest(ctx, doc.ID, []docs.Document{doc})
+}
+
+// parse returns the documents found at path and the doc id that identifies
+// the file as a whole: the archive name for bundles, the doc id otherwise.
+func (p *Pipeline) parse(path string) ([]docs.Document, string, error) {
+	parsed, err := docs.ParseAll(path, p.Parse, p.Archive)
+	if !common.IsNilValue(err) {
+		return nil, "", &ParseError{Path: path, Err: err}
+	}
+
+	// parsers name documents after the base name of the file and keep the
+	// path they read, which for uploads is a temporary file; both give way
+	// to the doc id
+	base, id := filepath.Base(path), DocID(p.Root, path)
+	for i := range parsed {
+		d := &parsed[i]
+		if d.ID == base {
+			d.ID = id
+		} else if strings.HasPrefix(d.ID, base+"!/") {
+			d.ID = id + strings.TrimPrefix(d.ID, base)
+		}
+		d.Path = d.ID
+		if d.Bundle == base {
+			d.Bundle = id
+		}
+	}
+	if docs.IsArchive(path) {
+		return parsed, id, nil
+	}
+	return parsed, parsed[0].ID, nil
+}
+
+// DocID names the file at path: its slash separated path below root, or
+// its base name when root is empty or does not hold path.
+func DocID(root, path string) string {
+	if root != "" {
+		rel, err := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		if err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, "../") {
+			return rel
+		}
+	}
+	return filepath.Base(path)
+}
+
+func (p *Pipeline) ingest(ctx context.Context, id string, parsed []docs.Document) (Result, error) {
+	res := Result{DocID: id}
+	res.Warnings, res.EmptyPages = warnings(parsed)
+
+	t0 := time.Now()
+	chunks, tokens, err := p.chunkAll(ctx, parsed)
+	res.ParseChunk = time.Since(t0)
+	if !common.IsNilValue(err) {
+		return res, err
+	}
+	res.Chunks = len(chunks)
+	res.Tokens = tokens
+	if len(chunks) == 0 {
+		return res, nil
+	}
+
+	var points []store.Point
+	for _, batch := range p.batches(chunks) {
+		t1 := time.Now()
+		pts, err := p.embedBatch(ctx, id, batch)
+		res.Embed += time.Since(t1)
+		if !common.IsNilValue(err) {
+			return res, err
+		}
+		points = append(points, pts...)
+		res.Vectors += len(pts)
+	}
+
+	t2 := time.Now()
+	if err := p.Store.Upsert(ctx, points); err != nil {
+		return res, fmt.Errorf("upsert %s: %w", id, err)
+	}
+	res.Upsert = time.Since(t2)
+	res.PointKeys = pointKeys(points)
+
+	return res, nil
+}
+
+// warnings collects the page warnings of parsed and counts the pages that
+// had no text.
+func warnings(parsed []docs.Document) ([]Warning, int) {
+	var (
+		out   []Warning
+		empty int
+	)
+	for _, doc := range parsed {
+		for _, w := range doc.Warnings {
+			out = append(out, Warning{DocID: doc.ID, PageWarning: w})
+			if w.Kind == docs.PageEmpty {
+				empty++
+			}
+		}
+	}
+	return out, empty
+}
+
+func (p *Pipeline) chunkAll(ctx context.Context, parsed []docs.Document) ([]docs.Chunk, int, error) {
+	var (
+		out    []docs.Chunk
+		tokens int
+	)
+	for _, doc := range parsed {
+		chunks, n, err := p.chunk(ctx, doc)
+		if !common.IsNilValue(err) {
+			return nil, 0, err
+		}
+		out = append(out, chunks...)
+		tokens += n
+	}
+	return out, tokens, nil
+}
+
+func (p *Pipeline) chunk(ctx context.Context, doc docs.Document) ([]docs.Chunk, int, error) {
+	tok := p.tokenizer()
+
+	var chunks []docs.Chunk
+	if len(doc.Records) > 0 {
+		var err error
+		chunks, err = docs.ChunkRecords(doc, p.Records, tok, p.ChunkTarget)
+		if !common.IsNilValue(err) {
+			return nil, 0, fmt.Errorf("chunk %s: %w", doc.ID, err)
+		}
+	} else if doc.Language != "" {
+		chunks = docs.ChunkCode(doc, tok, p.ChunkTarget)
+	} else {
+		split, err := p.splitter(ctx, tok)
+		if !common.IsNilValue(err) {
+			return nil, 0, err
+		}
+		chunks, err = docs.Split(doc, split)
+		if !common.IsNilValue(err) {
+			return nil, 0, err
+		}
+	}
+
+	tokens := 0
+	stored := make(map[string]bool)
+	for i := range chunks {
+		chunks[i].Title = doc.Title
+		chunks[i].Bundle = doc.Bundle
+		chunks[i].Meta = doc.Meta
+		tokens += tok.Count(chunks[i].Text)
+
+		// a parent's text is stored once, on its first child; the others
+		// only refer to it by id
+		if parent := chunks[i].Parent; parent != nil {
+			if stored[parent.ID] {
+				chunks[i].Parent = &docs.Parent{ID: parent.ID}
+			}
+			stored[parent.ID] = true
+		}
+	}
+	return chunks, tokens, nil
+}
+
+// splitter builds the text chunker for Strategy, nested under parent
+// chunks when ParentTokens is set. It is built per document because parent
+// ids are numbered per document.
+func (p *Pipeline) splitter(ctx context.Context, tok tokenizer.Tokenizer) (docs.Splitter, error) {
+	var split docs.Splitter
+	switch p.Strategy {
+	case "", "tokens":
+		split = docs.TokenSplitter(tok, p.ChunkTarget, p.ChunkOverlap)
+	case "sentences":
+		split = docs.SentenceSplitter(tok, p.ChunkTarget, p.ChunkOverlap)
+	case "semantic":
+		opts := p.Semantic
+		opts.MaxTokens, opts.BatchSize = p.ChunkTarget, p.BatchSize
+		split = docs.SemanticSplitter(ctx, p.Embed, tok, opts)
+	default:
+		return nil, fmt.Errorf("unknown chunk strategy %q", p.Strategy)
+	}
+
+	if p.ParentTokens > 0 {
+		split = docs.WithParents(split, tok, p.ParentTokens)
+	}
+	return split, nil
+}
+
+// tokenizer returns the configured tokenizer, counting words when unset.
+func (p *Pipeline) tokenizer() tokenizer.Tokenizer {
+	if p.Tokenizer == nil {
+		return tokenizer.Words{}
+	}
+	return p.Tokenizer
+}
+
+func (p *Pipeline) batches(chunks []docs.Chunk) [][]docs.Chunk {
+	size := p.BatchSize
+	if size <= 0 {
+		size = 64
+	}
+
+	var out [][]docs.Chunk
+	for i := 0; i < len(chunks); i += size {
+		out = append(out, chunks[i:min(i+size, len(chunks))])
+	}
+	return out
+}
+
+func (p *Pipeline) embedBatch(ctx context.Context, docID string, chunks []docs.Chunk) ([]store.Point, error) {
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = c.Text
+	}
+
+	vecs, err := p.Embed.Embed(ctx, texts)
+	if !common.IsNilValue(err) {
+		return nil, fmt.Errorf("embed %s: %w", docID, err)
+	}
+	if len(vecs) != len(texts) {
+		return nil, fmt.Errorf("embed %s: got %d vectors for %d chunks", docID, len(vecs), len(texts))
+	}
+
+	if err := p.ensureCollection(ctx, len(vecs[0])); err != nil {
+		return nil, fmt.Errorf("ensure collection: %w", err)
+	}
+
+	points := make([]store.Point, len(vecs))
+	for i, c := range chunks {
+		points[i] = store.Point{
+			ID:      store.PointID(c.DocID, c.ChunkID),
+			Vector:  vecs[i],
+			Payload: chunkPayload(c),
+		}
+	}
+	return points, nil
+}
+
+func pointKeys(points []store.Point) []string {
+	keys := make([]string, len(points))
+	for i, pt := range points {
+		keys[i], _ = pt.Payload["point_key"].(string)
+	}
+	return keys
+}
+
+func chunkPayload(c docs.Chunk) map[string]any {
+	payload := map[string]any{
+		"doc_id":    c.DocID,
+		"page":      c.Page,
+		"chunk_id":  c.ChunkID,
+		"index":     c.Index,
+		"point_key": store.PointKey(c.DocID, c.ChunkID),
+		"text":      c.Text,
+	}
+	if c.Section != "" {
+		payload["section"] = c.Section
+	}
+	if c.Chapter != "" {
+		payload["chapter"] = c.Chapter
+	}
+	if c.Title != "" {
+		payload["title"] = c.Title
+	}
+	if c.Bundle != "" {
+		payload["bundle"] = c.Bundle
+	}
+	if len(c.Meta) > 0 {
+		payload["meta"] = c.Meta
+	}
+	if len(c.Fields) > 0 {
+		payload["fields"] = c.Fields
+	}
+	if c.Span != nil {
+		payload["start"] = c.Span.Start
+		payload["end"] = c.Span.End
+		if c.Page > 0 {
+			payload["page_start"] = c.Span.PageStart
+			payload["page_end"] = c.Span.PageEnd
+		}
+	}
+	if c.Parent != nil {
+		payload["parent_id"] = c.Parent.ID
+		if c.Parent.Text != "" {
+			payload["parent_text"] = c.Parent.Text
+		}
+	}
+	if c.Code != nil {
+		payload["path"] = c.Code.Path
+		payload["language"] = c.Code.Language
+		payload["start_line"] = c.Code.StartLine
+		payload["end_line"] = c.Code.EndLine
+		if c.Code.Symbol != "" {
+			payload["symbol"] = c.Code.Symbol
+			payload["kind"] = c.Code.Kind
+		}
+	}
+	return payload
+}
+
+func (p *Pipeline) ensureCollection(ctx context.Context, dim int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.dim == dim {
+		return nil
+	}
+	if err := p.Store.EnsureCollection(ctx, dim); err != nil {
+		return err
+	}
+	p.dim = dim
+	return nil
+}
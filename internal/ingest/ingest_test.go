@@ -0,0 +1,81 @@
+package ingest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDocID(t *testing.T) {
+	tests := []struct {
+		root, path, want string
+	}{
+		{"", "data/pkg/main.go", "main.go"},
+		{"data", "data/main.go", "main.go"},
+		{"data", "data/pkg/foo/main.go", "pkg/foo/main.go"},
+		{"data/", "data/pkg/main.go", "pkg/main.go"},
+		{"data", "other/main.go", "main.go"},
+		{"data/main.go", "data/main.go", "main.go"},
+	}
+	for _, tt := range tests {
+		if got := DocID(tt.root, tt.path); got != tt.want {
+			t.Errorf("DocID(%q, %q) = %q, want %q", tt.root, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseNamesDocumentsBelowRoot(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, body string) string {
+		path := filepath.Join(root, rel)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	a := write("a/main.go", "package main\n\nfunc main() {}\n")
+	b := write("b/main.go", "package main\n\nfunc main() { println() }\n")
+	bundle := filepath.Join(root, "sub", "bundle.zip")
+	os.MkdirAll(filepath.Dir(bundle), 0o755)
+	writeZip(t, bundle, map[string]string{"inner/main.go": "package inner\n"})
+
+	p := &Pipeline{Root: root, ChunkTarget: 50}
+	ids := make(map[string]bool)
+	for _, path := range []string{a, b} {
+		parsed, id, err := p.parse(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != parsed[0].ID || ids[id] {
+			t.Errorf("%s named %q, docs %q", path, id, parsed[0].ID)
+		}
+		ids[id] = true
+		chunks, _, err := p.chunkAll(t.Context(), parsed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range chunks {
+			if c.DocID != id {
+				t.Errorf("chunk of %s has doc id %q", id, c.DocID)
+			}
+			if c.Code == nil || c.Code.Path != id {
+				t.Errorf("chunk of %s has code span %+v", id, c.Code)
+			}
+		}
+	}
+	if !ids["a/main.go"] || !ids["b/main.go"] {
+		t.Errorf("ids = %v", ids)
+	}
+
+	parsed, id, err := p.parse(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "sub/bundle.zip" || len(parsed) != 1 {
+		t.Fatalf("bundle named %q with %d docs", id, len(parsed))
+	}
+	if d := parsed[0]; d.ID != "sub/bundle.zip!/inner/main.go" || d.Path != d.ID || d.Bundle != "sub/bundle.zip" {
+		t.Errorf("member = %q at %q in %q", d.ID, d.Path, d.Bundle)
+	}
+}
@@ -0,0 +1,132 @@
+package ingest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/brunomgama/go_rag/internal/common"
+)
+
+// ManifestEntry records how a file was indexed, so later runs can tell
+// whether it needs to be embedded again.
+type ManifestEntry struct {
+	Path               string    `json:"path"`
+	DocID              string    `json:"doc_id"`
+	Checksum           string    `json:"checksum"`
+	Collection         string    `json:"collection"`
+	ChunkTarget        int       `json:"chunk_target"`
+	ChunkOverlap       int       `json:"chunk_overlap"`
+	ChunkStrategy      string    `json:"chunk_strategy,omitempty"`
+	SemanticPercentile float64   `json:"semantic_break_percentile,omitempty"`
+	SemanticMinTokens  int       `json:"semantic_min_tokens,omitempty"`
+	ParentTokens       int       `json:"parent_tokens,omitempty"`
+	Tokenizer          string    `json:"tokenizer,omitempty"`
+	RecordsPerChunk    int       `json:"records_per_chunk,omitempty"`
+	RecordTemplate     string    `json:"record_template,omitempty"`
+	RecordFields       []string  `json:"record_fields,omitempty"`
+	PDFMaxPages        int       `json:"pdf_max_pages,omitempty"`
+	EmbeddingsModel    string    `json:"embeddings_model"`
+	Chunks             int       `json:"chunks"`
+	IngestedAt         time.Time `json:"ingested_at"`
+}
+
+// Same reports whether e was produced from identical content with identical
+// chunking and embedding settings.
+func (e ManifestEntry) Same(o ManifestEntry) bool {
+	return e.DocID == o.DocID &&
+		e.Checksum == o.Checksum &&
+		e.Collection == o.Collection &&
+		e.ChunkTarget == o.ChunkTarget &&
+		e.ChunkOverlap == o.ChunkOverlap &&
+		e.ChunkStrategy == o.ChunkStrategy &&
+		e.SemanticPercentile == o.SemanticPercentile &&
+		e.SemanticMinTokens == o.SemanticMinTokens &&
+		e.ParentTokens == o.ParentTokens &&
+		e.Tokenizer == o.Tokenizer &&
+		e.RecordsPerChunk == o.RecordsPerChunk &&
+		e.RecordTemplate == o.RecordTemplate &&
+		slices.Equal(e.RecordFields, o.RecordFields) &&
+		e.PDFMaxPages == o.PDFMaxPages &&
+		e.EmbeddingsModel == o.EmbeddingsModel
+}
+
+// Manifest holds one set of entries per collection, keyed by file path.
+type Manifest struct {
+	path        string
+	Collections map[string]map[string]ManifestEntry `json:"collections"`
+}
+
+func LoadManifest(path string) (*Manifest, error) {
+	m := &Manifest{path: path, Collections: make(map[string]map[string]ManifestEntry)}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if !common.IsNilValue(err) {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("manifest %s: %w", path, err)
+	}
+	if m.Collections == nil {
+		m.Collections = make(map[string]map[string]ManifestEntry)
+	}
+	return m, nil
+}
+
+func (m *Manifest) Get(collection, path string) (ManifestEntry, bool) {
+	e, ok := m.Collections[collection][path]
+	return e, ok
+}
+
+func (m *Manifest) Put(e ManifestEntry) {
+	entries, ok := m.Collections[e.Collection]
+	if !ok {
+		entries = make(map[string]ManifestEntry)
+		m.Collections[e.Collection] = entries
+	}
+	entries[e.Path] = e
+}
+
+func (m *Manifest) Remove(collection, path string) {
+	delete(m.Collections[collection], path)
+	if len(m.Collections[collection]) == 0 {
+		delete(m.Collections, collection)
+	}
+}
+
+// Paths returns the paths recorded for collection in sorted order.
+func (m *Manifest) Paths(collection string) []string {
+	out := make([]string, 0, len(m.Collections[collection]))
+	for p := range m.Collections[collection] {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (m *Manifest) Save() error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if !common.IsNilValue(err) {
+		return err
+	}
+	if dir := filepath.Dir(m.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.path)
+}
@@ -0,0 +1,119 @@
+package ingest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testEntry(path string) ManifestEntry {
+	return ManifestEntry{
+		Path:               path,
+		DocID:              filepath.Base(path),
+		Checksum:           "abc",
+		Collection:         "docs",
+		ChunkTarget:        800,
+		ChunkOverlap:       100,
+		ChunkStrategy:      "semantic",
+		SemanticPercentile: 90,
+		SemanticMinTokens:  50,
+		ParentTokens:       2000,
+		Tokenizer:          "cl100k.tiktoken",
+		RecordsPerChunk:    5,
+		RecordTemplate:     "{{.name}}",
+		RecordFields:       []string{"name", "cost"},
+		PDFMaxPages:        100,
+		EmbeddingsModel:    "nomic-embed-text",
+		Chunks:             12,
+		IngestedAt:         time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "manifest.json")
+	m, err := LoadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Collections) != 0 {
+		t.Fatalf("missing file loaded %d collections", len(m.Collections))
+	}
+
+	a, b := testEntry("data/b.md"), testEntry("data/a.md")
+	other := testEntry("data/a.md")
+	other.Collection = "other"
+	for _, e := range []ManifestEntry{a, b, other} {
+		m.Put(e)
+	}
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Collections, m.Collections) {
+		t.Errorf("loaded %+v\nwant %+v", loaded.Collections, m.Collections)
+	}
+	if got := loaded.Paths("docs"); !reflect.DeepEqual(got, []string{"data/a.md", "data/b.md"}) {
+		t.Errorf("paths = %q", got)
+	}
+	if got, ok := loaded.Get("docs", "data/b.md"); !ok || !got.Same(a) {
+		t.Errorf("Get = %+v, %v", got, ok)
+	}
+
+	loaded.Remove("other", "data/a.md")
+	if _, ok := loaded.Collections["other"]; ok {
+		t.Error("empty collection kept after Remove")
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temp file left behind: %v", err)
+	}
+}
+
+func TestLoadManifestCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	os.WriteFile(path, []byte(`{"collections": [`), 0o644)
+	if _, err := LoadManifest(path); err == nil {
+		t.Error("no error for a corrupt manifest")
+	}
+}
+
+func TestManifestEntrySame(t *testing.T) {
+	base := testEntry("data/a.md")
+
+	// bookkeeping fields do not make an entry differ
+	same := base
+	same.Chunks, same.IngestedAt = 3, time.Now()
+	if !base.Same(same) {
+		t.Error("entries differing in Chunks and IngestedAt are not Same")
+	}
+
+	changes := map[string]func(*ManifestEntry){
+		"doc id":              func(e *ManifestEntry) { e.DocID = "x" },
+		"checksum":            func(e *ManifestEntry) { e.Checksum = "x" },
+		"collection":          func(e *ManifestEntry) { e.Collection = "x" },
+		"chunk target":        func(e *ManifestEntry) { e.ChunkTarget++ },
+		"chunk overlap":       func(e *ManifestEntry) { e.ChunkOverlap++ },
+		"strategy":            func(e *ManifestEntry) { e.ChunkStrategy = "tokens" },
+		"semantic percentile": func(e *ManifestEntry) { e.SemanticPercentile++ },
+		"semantic min tokens": func(e *ManifestEntry) { e.SemanticMinTokens++ },
+		"parent tokens":       func(e *ManifestEntry) { e.ParentTokens++ },
+		"tokenizer":           func(e *ManifestEntry) { e.Tokenizer = "" },
+		"records per chunk":   func(e *ManifestEntry) { e.RecordsPerChunk++ },
+		"record template":     func(e *ManifestEntry) { e.RecordTemplate = "" },
+		"record fields":       func(e *ManifestEntry) { e.RecordFields = []string{"name"} },
+		"pdf max pages":       func(e *ManifestEntry) { e.PDFMaxPages++ },
+		"embeddings model":    func(e *ManifestEntry) { e.EmbeddingsModel = "x" },
+	}
+	for name, change := range changes {
+		e := testEntry("data/a.md")
+		change(&e)
+		if base.Same(e) {
+			t.Errorf("entries differing in %s are Same", name)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/brunomgama/go_rag/internal/common"
+	"github.com/brunomgama/go_rag/internal/docs"
+	"github.com/brunomgama/go_rag/internal/store"
+)
+
+// Workers sets the number of goroutines per stage of Run. Zero values fall
+// back to one worker.
+type Workers struct {
+	Parse  int
+	Chunk  int
+	Embed  int
+	Upsert int
+}
+
+type FileResult struct {
+	Path string
+	Result
+	Err error
+}
+
+type parsedFile struct {
+	path   string
+	id     string
+	docs   []docs.Document
+	parsed time.Duration
+}
+
+type chunkBatch struct {
+	doc    *docTracker
+	chunks []docs.Chunk
+}
+
+type pointBatch struct {
+	doc    *docTracker
+	points []store.Point
+}
+
+// docTracker collects the results of one file, which holds several
+// documents when it is an archive, while its batches move through the
+// embed and upsert stages concurrently.
+type docTracker struct {
+	mu      sync.Mutex
+	path    string
+	res     Result
+	pending int
+	err     error
+}
+
+// Run streams paths through parse → chunk → embed → upsert stages connected
+// by unbuffered channels, so a slow stage holds back the ones before it.
+// Exactly one FileResult is sent per path, once all of its batches are
+// upserted or one of them fails. The returned channel is closed when every
+// stage has drained; cancelling ctx stops all stages early.
+func (p *Pipeline) Run(ctx context.Context, paths <-chan string) <-chan FileResult {
+	parsed := make(chan parsedFile)
+	batches := make(chan chunkBatch)
+	points := make(chan pointBatch)
+	results := make(chan FileResult)
+
+	send := func(r FileResult) {
+		select {
+		case results <- r:
+		case <-ctx.Done():
+		}
+	}
+
+	parseWG := stage(workers(p.Workers.Parse), func() {
+		for {
+			path, ok := next(ctx, paths)
+			if !ok {
+				return
+			}
+			t0 := time.Now()
+			found, id, err := p.parse(path)
+			if !common.IsNilValue(err) {
+				send(FileResult{Path: path, Err: err})
+				continue
+			}
+			select {
+			case parsed <- parsedFile{path: path, id: id, docs: found, parsed: time.Since(t0)}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	chunkWG := stage(workers(p.Workers.Chunk), func() {
+		for {
+			d, ok := next(ctx, parsed)
+			if !ok {
+				return
+			}
+			t0 := time.Now()
+			chunks, tokens, err := p.chunkAll(ctx, d.docs)
+			tr := &docTracker{path: d.path, res: Result{
+				DocID:      d.id,
+				Chunks:     len(chunks),
+				Tokens:     tokens,
+				ParseChunk: d.parsed + time.Since(t0),
+			}}
+			tr.res.Warnings, tr.res.EmptyPages = warnings(d.docs)
+			if err != nil || len(chunks) == 0 {
+				send(FileResult{Path: d.path, Result: tr.res, Err: err})
+				continue
+			}
+
+			groups := p.batches(chunks)
+			tr.pending = len(groups)
+			for _, b := range groups {
+				select {
+				case batches <- chunkBatch{doc: tr, chunks: b}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	})
+
+	embedWG := stage(workers(p.Workers.Embed), func() {
+		for {
+			b, ok := next(ctx, batches)
+			if !ok {
+				return
+			}
+
+			t0 := time.Now()
+			pts, err := p.embedBatch(ctx, b.doc.res.DocID, b.chunks)
+			if r, done := b.doc.embedded(time.Since(t0), err); done {
+				send(r)
+			}
+			if err != nil {
+				continue
+			}
+			select {
+			case points <- pointBatch{doc: b.doc, points: pts}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	upsertWG := stage(workers(p.Workers.Upsert), func() {
+		for {
+			b, ok := next(ctx, points)
+			if !ok {
+				return
+			}
+
+			t0 := time.Now()
+			err := p.Store.Upsert(ctx, b.points)
+			if err != nil {
+				err = fmt.Errorf("upsert %s: %w", b.doc.res.DocID, err)
+			}
+			if r, done := b.doc.complete(b.points, time.Since(t0), err); done {
+				send(r)
+			}
+		}
+	})
+
+	go func() {
+		parseWG.Wait()
+		close(parsed)
+		chunkWG.Wait()
+		close(batches)
+		embedWG.Wait()
+		close(points)
+		upsertWG.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// embedded records the embed stage of one batch. A failed batch ends the
+// document, so the first failure is reported as its result; successful
+// batches still have to pass through the upsert stage.
+func (t *docTracker) embedded(took time.Duration, err error) (FileResult, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.res.Embed += took
+	if err == nil {
+		return FileResult{}, false
+	}
+	t.pending--
+	if t.err != nil {
+		return FileResult{}, false
+	}
+	t.err = err
+	return FileResult{Path: t.path, Result: t.res, Err: err}, true
+}
+
+// complete records an upserted batch and reports the document as done once
+// its last batch is through, unless a failure was already reported.
+func (t *docTracker) complete(points []store.Point, took time.Duration, err error) (FileResult, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pending--
+	t.res.Upsert += took
+	if err == nil {
+		t.res.Vectors += len(points)
+		t.res.PointKeys = append(t.res.PointKeys, pointKeys(points)...)
+	}
+	if t.err != nil {
+		return FileResult{}, false
+	}
+	if err != nil {
+		t.err = err
+		return FileResult{Path: t.path, Result: t.res, Err: err}, true
+	}
+	if t.pending > 0 {
+		return FileResult{}, false
+	}
+	return FileResult{Path: t.path, Result: t.res}, true
+}
+
+func stage(n int, work func()) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			work()
+		}()
+	}
+	return &wg
+}
+
+// next receives from in, reporting false once it is closed or ctx is done.
+func next[T any](ctx context.Context, in <-chan T) (T, bool) {
+	select {
+	case v, ok := <-in:
+		return v, ok
+	case <-ctx.Done():
+		var zero T
+		return zero, false
+	}
+}
+
+func workers(n int) int {
+	return max(1, n)
+}
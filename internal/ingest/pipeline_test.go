@@ -0,0 +1,304 @@
+package ingest
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brunomgama/go_rag/internal/store"
+)
+
+// fakeEmbedder fails any batch holding a text with fail in it and, when
+// block is set, waits for ctx to be cancelled instead of answering.
+type fakeEmbedder struct {
+	fail  string
+	block bool
+
+	mu     sync.Mutex
+	calls  int
+	called chan struct{}
+}
+
+func (e *fakeEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.mu.Lock()
+	e.calls++
+	if e.called != nil && e.calls == 1 {
+		close(e.called)
+	}
+	e.mu.Unlock()
+
+	if e.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		if e.fail != "" && strings.Contains(text, e.fail) {
+			return nil, errors.New("embedding backend down")
+		}
+		out[i] = []float32{1, float32(len(text))}
+	}
+	return out, nil
+}
+
+// failingStore rejects upserts that carry a point of failDoc.
+type failingStore struct {
+	*store.Memory
+	failDoc string
+}
+
+func (s *failingStore) Upsert(ctx context.Context, points []store.Point) error {
+	for _, p := range points {
+		if p.Payload["doc_id"] == s.failDoc {
+			return errors.New("disk full")
+		}
+	}
+	return s.Memory.Upsert(ctx, points)
+}
+
+func words(prefix string, n int) string {
+	w := make([]string, n)
+	for i := range w {
+		w[i] = fmt.Sprintf("%s%d", prefix, i)
+	}
+	return strings.Join(w, " ")
+}
+
+func writeZip(t *testing.T, path string, members map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, body := range members {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+}
+
+func newTestPipeline(t *testing.T, emb *fakeEmbedder, failDoc string) (*Pipeline, *store.Memory) {
+	t.Helper()
+	mem, err := store.NewMemory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Pipeline{
+		Embed:       emb,
+		Store:       &failingStore{Memory: mem, failDoc: failDoc},
+		ChunkTarget: 5,
+		BatchSize:   1, // one batch per chunk
+		Workers:     Workers{Parse: 2, Chunk: 2, Embed: 3, Upsert: 2},
+	}, mem
+}
+
+// waitGoroutines fails the test unless the goroutine count drops back to
+// base, giving exiting goroutines a moment to finish.
+func waitGoroutines(t *testing.T, base int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > base {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines left, want %d:\n%s", runtime.NumGoroutine(), base, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRunReportsEachFileOnce(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"ok.txt":     words("ok", 20),                                    // 4 chunks
+		"embed.txt":  words("a", 10) + " FAIL " + words("b", 9),          // third of 4 chunks fails
+		"upsert.txt": words("u", 12),                                     // every upsert fails
+		"empty.txt":  "",                                                 // no chunks
+		"bundle.zip": "",                                                 // written below
+		"gone.txt":   "",                                                 // removed below
+		"embed2.txt": "FAIL " + words("c", 9) + " FAIL " + words("d", 9), // several batches fail
+	}
+	var paths []string
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+		paths = append(paths, path)
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Remove(filepath.Join(dir, "gone.txt"))
+	writeZip(t, filepath.Join(dir, "bundle.zip"), map[string]string{
+		"one.txt":     words("x", 10), // 2 chunks
+		"sub/two.txt": words("y", 5),  // 1 chunk
+	})
+
+	base := runtime.NumGoroutine()
+	p, mem := newTestPipeline(t, &fakeEmbedder{fail: "FAIL"}, "upsert.txt")
+
+	in := make(chan string)
+	go func() {
+		defer close(in)
+		for _, path := range paths {
+			in <- path
+		}
+	}()
+
+	got := make(map[string]FileResult)
+	for r := range p.Run(context.Background(), in) {
+		name := filepath.Base(r.Path)
+		if _, dup := got[name]; dup {
+			t.Errorf("second result for %s: %+v", name, r)
+		}
+		got[name] = r
+	}
+	if len(got) != len(files) {
+		t.Errorf("got %d results for %d files", len(got), len(files))
+	}
+
+	// vectors of -1 are not checked: a failed file is reported as soon as
+	// one batch fails, while its other batches may still be in flight
+	check := func(name string, chunks, vectors int, errText string) {
+		t.Helper()
+		r, ok := got[name]
+		if !ok {
+			t.Errorf("no result for %s", name)
+			return
+		}
+		if r.Chunks != chunks || vectors >= 0 && r.Vectors != vectors {
+			t.Errorf("%s: chunks %d vectors %d, want %d and %d", name, r.Chunks, r.Vectors, chunks, vectors)
+		}
+		switch {
+		case errText == "" && r.Err != nil:
+			t.Errorf("%s: unexpected error %v", name, r.Err)
+		case errText != "" && (r.Err == nil || !strings.Contains(r.Err.Error(), errText)):
+			t.Errorf("%s: err = %v, want %q", name, r.Err, errText)
+		}
+	}
+	check("ok.txt", 4, 4, "")
+	check("empty.txt", 0, 0, "")
+	check("bundle.zip", 3, 3, "")
+	check("embed.txt", 4, -1, "embed embed.txt: embedding backend down")
+	check("embed2.txt", 4, -1, "embed embed2.txt")
+	check("upsert.txt", 3, 0, "upsert upsert.txt: disk full")
+
+	var parseErr *ParseError
+	if r := got["gone.txt"]; !errors.As(r.Err, &parseErr) {
+		t.Errorf("gone.txt: err = %v, want a ParseError", r.Err)
+	}
+	if r := got["bundle.zip"]; r.DocID != "bundle.zip" {
+		t.Errorf("bundle.zip reported as %q", r.DocID)
+	}
+
+	page, err := mem.Scroll(context.Background(), store.ScrollRequest{Limit: 100, WithPayload: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	perDoc := make(map[string]int)
+	for _, rec := range page.Points {
+		perDoc[rec.Payload["doc_id"].(string)]++
+	}
+	want := map[string]int{"ok.txt": 4, "bundle.zip!/one.txt": 2, "bundle.zip!/sub/two.txt": 1}
+	for doc, n := range want {
+		if perDoc[doc] != n {
+			t.Errorf("store holds %d points of %s, want %d", perDoc[doc], doc, n)
+		}
+	}
+	if perDoc["upsert.txt"] != 0 {
+		t.Errorf("store holds points of upsert.txt")
+	}
+
+	waitGoroutines(t, base)
+}
+
+func TestRunFailedFileReportedOnce(t *testing.T) {
+	// batches of one file race through the embed and upsert workers; the
+	// failure must be its only result, whichever order they finish in
+	dir := t.TempDir()
+	path := filepath.Join(dir, "partial.txt")
+	os.WriteFile(path, []byte(words("a", 10)+" FAIL "+words("b", 9)), 0o644)
+
+	for range 20 {
+		p, _ := newTestPipeline(t, &fakeEmbedder{fail: "FAIL"}, "")
+		in := make(chan string, 1)
+		in <- path
+		close(in)
+
+		var results []FileResult
+		for r := range p.Run(context.Background(), in) {
+			results = append(results, r)
+		}
+		if len(results) != 1 {
+			t.Fatalf("got %d results, want 1", len(results))
+		}
+		if r := results[0]; r.Err == nil || r.Chunks != 4 || r.Vectors > 3 {
+			t.Fatalf("result = %+v", r)
+		}
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i := range 5 {
+		path := filepath.Join(dir, fmt.Sprintf("f%d.txt", i))
+		os.WriteFile(path, []byte(words("w", 20)), 0o644)
+		paths = append(paths, path)
+	}
+
+	base := runtime.NumGoroutine()
+	emb := &fakeEmbedder{block: true, called: make(chan struct{})}
+	p, _ := newTestPipeline(t, emb, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan string) // never closed: only cancellation ends the run
+	go func() {
+		for _, path := range paths {
+			select {
+			case in <- path:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	results := p.Run(ctx, in)
+	select {
+	case <-emb.called:
+	case <-time.After(5 * time.Second):
+		t.Fatal("embedder never called")
+	}
+	cancel()
+
+	done := make(chan int)
+	go func() {
+		n := 0
+		for range results {
+			n++
+		}
+		done <- n
+	}()
+	select {
+	case n := <-done:
+		if n > len(paths) {
+			t.Errorf("got %d results for %d paths", n, len(paths))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("results channel not closed after cancel")
+	}
+
+	waitGoroutines(t, base)
+}
@@ -0,0 +1,44 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brunomgama/go_rag/internal/config"
+)
+
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type Generator interface {
+	Generate(ctx context.Context, messages []Message) (string, error)
+}
+
+// Streamer is implemented by generators that can deliver tokens as they are
+// produced. The full text is still returned once the stream ends.
+type Streamer interface {
+	GenerateStream(ctx context.Context, messages []Message, onToken func(string) error) (string, error)
+}
+
+func FromConfig(cfg config.Config) (Generator, error) {
+	switch cfg.LLMProvider {
+	case "", "ollama":
+		c := NewOllama(cfg.OllamaHost, cfg.LLMModel)
+		c.Temperature = cfg.LLMTemperature
+		return c, nil
+	case "openai":
+		c := NewOpenAI(cfg.OpenAIBaseURL, cfg.OpenAIAPIKey, cfg.LLMModel)
+		c.Temperature = cfg.LLMTemperature
+		return c, nil
+	default:
+		return nil, fmt.Errorf("unknown llm provider %q", cfg.LLMProvider)
+	}
+}
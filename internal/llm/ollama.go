@@ -4,21 +4,26 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/brunomgama/go_rag/internal/common"
 )
 
 type Client struct {
-	host  string
-	model string
-	http  *http.Client
+	host        string
+	model       string
+	http        *http.Client
+	Temperature float64
 }
 
 type generatedRequest struct {
 	Model   string         `json:"model"`
+	System  string         `json:"system,omitempty"`
 	Prompt  string         `json:"prompt"`
 	Stream  bool           `json:"stream"`
 	Options map[string]any `json:"options,omitempty"`
@@ -26,6 +31,8 @@ type generatedRequest struct {
 
 type generatedResponse struct {
 	Response string `json:"response"`
+	Done     bool   `json:"done"`
+	Error    string `json:"error"`
 }
 
 func NewOllama(host, model string) *Client {
@@ -34,19 +41,22 @@ func NewOllama(host, model string) *Client {
 	}
 
 	return &Client{
-		host:  host,
-		model: model,
-		http:  &http.Client{Timeout: 60 * time.Second},
+		host:        host,
+		model:       model,
+		http:        &http.Client{Timeout: 60 * time.Second},
+		Temperature: 0.2,
 	}
 }
 
-func (c *Client) Generate(ctx context.Context, prompt string) (string, error) {
+func (c *Client) Generate(ctx context.Context, messages []Message) (string, error) {
+	system, prompt := flattenMessages(messages)
 	body, _ := json.Marshal(generatedRequest{
 		Model:  c.model,
+		System: system,
 		Prompt: prompt,
 		Stream: false,
 		Options: map[string]any{
-			"temperature": 0.2,
+			"temperature": c.Temperature,
 		},
 	})
 
@@ -72,3 +82,80 @@ func (c *Client) Generate(ctx context.Context, prompt string) (string, error) {
 	return out.Response, nil
 
 }
+
+// GenerateStream reads Ollama's NDJSON stream, one generatedResponse per line,
+// until a line reports done. A stream that ends before that was cut off and
+// fails with io.ErrUnexpectedEOF.
+func (c *Client) GenerateStream(ctx context.Context, messages []Message, onToken func(string) error) (string, error) {
+	system, prompt := flattenMessages(messages)
+	body, _ := json.Marshal(generatedRequest{
+		Model:  c.model,
+		System: system,
+		Prompt: prompt,
+		Stream: true,
+		Options: map[string]any{
+			"temperature": c.Temperature,
+		},
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", c.host+"/api/generate", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	// the client timeout would cut long generations short; ctx bounds the stream instead
+	streaming := *c.http
+	streaming.Timeout = 0
+	res, err := streaming.Do(req)
+
+	if !common.IsNilValue(err) {
+		return "", err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != 200 {
+		return "", fmt.Errorf("ollama generate status %d", res.StatusCode)
+	}
+
+	var full strings.Builder
+	dec := json.NewDecoder(res.Body)
+	for {
+		var part generatedResponse
+		err := dec.Decode(&part)
+		if errors.Is(err, io.EOF) {
+			return full.String(), fmt.Errorf("ollama generate stream: %w", io.ErrUnexpectedEOF)
+		}
+		if err != nil {
+			return full.String(), err
+		}
+		if part.Error != "" {
+			return full.String(), fmt.Errorf("ollama generate: %s", part.Error)
+		}
+
+		if part.Response != "" {
+			full.WriteString(part.Response)
+			if err := onToken(part.Response); err != nil {
+				return full.String(), err
+			}
+		}
+		if part.Done {
+			return full.String(), nil
+		}
+	}
+}
+
+// flattenMessages maps chat messages onto /api/generate, which only takes a
+// system string and a single prompt.
+func flattenMessages(messages []Message) (string, string) {
+	var system, prompt []string
+	for _, m := range messages {
+		switch m.Role {
+		case RoleSystem:
+			system = append(system, m.Content)
+		case RoleAssistant:
+			prompt = append(prompt, "Assistant: "+m.Content)
+		default:
+			prompt = append(prompt, m.Content)
+		}
+	}
+	return strings.Join(system, "\n\n"), strings.Join(prompt, "\n\n")
+}
@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testMessages = []Message{
+	{Role: RoleSystem, Content: "Be brief."},
+	{Role: RoleUser, Content: "Question?"},
+}
+
+// stubOllama serves /api/generate with the given status and body, and
+// records the last request it got.
+func stubOllama(t *testing.T, status int, body string) (*Client, *generatedRequest) {
+	t.Helper()
+	var got generatedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/generate" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return NewOllama(srv.URL, "test-model"), &got
+}
+
+func TestOllamaGenerateStream(t *testing.T) {
+	c, req := stubOllama(t, http.StatusOK, strings.Join([]string{
+		`{"response":"The ","done":false}`,
+		`{"response":"","done":false}`,
+		`{"response":"answer.","done":false}`,
+		`{"response":"","done":true,"eval_count":3}`,
+		`{"response":"ignored","done":false}`,
+	}, "\n"))
+
+	var tokens []string
+	full, err := c.GenerateStream(context.Background(), testMessages, func(tok string) error {
+		tokens = append(tokens, tok)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if full != "The answer." || !reflect.DeepEqual(tokens, []string{"The ", "answer."}) {
+		t.Errorf("full %q, tokens %q", full, tokens)
+	}
+	if !req.Stream || req.Model != "test-model" || req.System != "Be brief." || req.Prompt != "Question?" {
+		t.Errorf("request = %+v", *req)
+	}
+}
+
+func TestOllamaGenerateStreamErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+		full   string
+	}{
+		{"ends before done", http.StatusOK, `{"response":"The ","done":false}` + "\n", "unexpected EOF", "The "},
+		{"cut inside a line", http.StatusOK, `{"response":"The ","done":false}` + "\n" + `{"respon`, "unexpected EOF", "The "},
+		{"empty body", http.StatusOK, "", "unexpected EOF", ""},
+		{"error line", http.StatusOK, `{"response":"The ","done":false}` + "\n" + `{"error":"model unloaded"}`, "model unloaded", "The "},
+		{"status", http.StatusNotFound, `{"error":"model not found"}`, "status 404", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := stubOllama(t, tt.status, tt.body)
+			full, err := c.GenerateStream(context.Background(), testMessages, func(string) error { return nil })
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+			if full != tt.full {
+				t.Errorf("full = %q, want %q", full, tt.full)
+			}
+		})
+	}
+
+	c, _ := stubOllama(t, http.StatusOK, `{"response":"x","done":false}`+"\n")
+	_, err := c.GenerateStream(context.Background(), testMessages, func(string) error { return nil })
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("err = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestOllamaGenerateStreamStopsOnTokenError(t *testing.T) {
+	c, _ := stubOllama(t, http.StatusOK, `{"response":"a"}`+"\n"+`{"response":"b"}`+"\n"+`{"done":true}`)
+	stop := errors.New("client gone")
+	full, err := c.GenerateStream(context.Background(), testMessages, func(string) error { return stop })
+	if !errors.Is(err, stop) || full != "a" {
+		t.Errorf("GenerateStream = %q, %v; want %q, %v", full, err, "a", stop)
+	}
+}
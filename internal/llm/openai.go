@@ -0,0 +1,172 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/brunomgama/go_rag/internal/common"
+)
+
+// OpenAI talks to any server implementing the /v1/chat/completions protocol.
+type OpenAI struct {
+	baseURL     string
+	apiKey      string
+	model       string
+	http        *http.Client
+	Temperature float64
+}
+
+type chatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Temperature float64   `json:"temperature"`
+	Stream      bool      `json:"stream"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message      Message `json:"message"`
+		FinishReason string  `json:"finish_reason"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func NewOpenAI(baseURL, apiKey, model string) *OpenAI {
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+
+	return &OpenAI{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		apiKey:      apiKey,
+		model:       model,
+		http:        &http.Client{Timeout: 60 * time.Second},
+		Temperature: 0.2,
+	}
+}
+
+func (c *OpenAI) Generate(ctx context.Context, messages []Message) (string, error) {
+	body, _ := json.Marshal(chatRequest{
+		Model:       c.model,
+		Messages:    messages,
+		Temperature: c.Temperature,
+		Stream:      false,
+	})
+
+	res, err := c.post(ctx, body, c.http)
+	if !common.IsNilValue(err) {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	raw, _ := io.ReadAll(res.Body)
+	var out chatResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return "", fmt.Errorf("openai chat status %d: %s", res.StatusCode, common.Snippet(string(raw), 300))
+	}
+	if out.Error != nil {
+		return "", fmt.Errorf("openai chat status %d: %s", res.StatusCode, out.Error.Message)
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("openai chat status %d", res.StatusCode)
+	}
+	if len(out.Choices) == 0 {
+		return "", fmt.Errorf("openai chat: no choices returned")
+	}
+
+	return out.Choices[0].Message.Content, nil
+}
+
+type chatChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// GenerateStream consumes the server-sent events emitted when stream is true.
+// Each "data:" line carries a chatChunk; the stream ends with "data: [DONE]",
+// without which it fails with io.ErrUnexpectedEOF.
+func (c *OpenAI) GenerateStream(ctx context.Context, messages []Message, onToken func(string) error) (string, error) {
+	body, _ := json.Marshal(chatRequest{
+		Model:       c.model,
+		Messages:    messages,
+		Temperature: c.Temperature,
+		Stream:      true,
+	})
+
+	// the client timeout would cut long generations short; ctx bounds the stream instead
+	streaming := *c.http
+	streaming.Timeout = 0
+	res, err := c.post(ctx, body, &streaming)
+	if !common.IsNilValue(err) {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(res.Body)
+		return "", fmt.Errorf("openai chat status %d: %s", res.StatusCode, common.Snippet(string(raw), 300))
+	}
+
+	var full strings.Builder
+	sc := bufio.NewScanner(res.Body)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			return full.String(), nil
+		}
+
+		var chunk chatChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return full.String(), fmt.Errorf("openai chat stream: %w", err)
+		}
+		if chunk.Error != nil {
+			return full.String(), fmt.Errorf("openai chat stream: %s", chunk.Error.Message)
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+
+		token := chunk.Choices[0].Delta.Content
+		full.WriteString(token)
+		if err := onToken(token); err != nil {
+			return full.String(), err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return full.String(), err
+	}
+	return full.String(), fmt.Errorf("openai chat stream: %w", io.ErrUnexpectedEOF)
+}
+
+func (c *OpenAI) post(ctx context.Context, body []byte, client *http.Client) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if !common.IsNilValue(err) {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	return client.Do(req)
+}
@@ -0,0 +1,176 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// stubOpenAI serves /v1/chat/completions with the given status and body, and
+// records the last request it got.
+func stubOpenAI(t *testing.T, status int, body string) (*OpenAI, *chatRequest, *http.Header) {
+	t.Helper()
+	var (
+		got    chatRequest
+		header http.Header
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		header = r.Header.Clone()
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return NewOpenAI(srv.URL+"/v1/", "secret", "test-model"), &got, &header
+}
+
+func sse(events ...string) string {
+	var b strings.Builder
+	for _, e := range events {
+		b.WriteString("data: " + e + "\n\n")
+	}
+	return b.String()
+}
+
+func TestOpenAIGenerateStream(t *testing.T) {
+	c, req, _ := stubOpenAI(t, http.StatusOK, ": keep-alive\n\n"+sse(
+		`{"choices":[{"delta":{"role":"assistant"}}]}`,
+		`{"choices":[{"delta":{"content":"The "}}]}`,
+		`{"choices":[]}`,
+		`{"choices":[{"delta":{"content":"answer."}}]}`,
+		`{"choices":[{"delta":{},"finish_reason":"stop"}]}`,
+		`[DONE]`,
+		`{"choices":[{"delta":{"content":"ignored"}}]}`,
+	))
+
+	var tokens []string
+	full, err := c.GenerateStream(context.Background(), testMessages, func(tok string) error {
+		tokens = append(tokens, tok)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if full != "The answer." || !reflect.DeepEqual(tokens, []string{"The ", "answer."}) {
+		t.Errorf("full %q, tokens %q", full, tokens)
+	}
+	if !req.Stream || !reflect.DeepEqual(req.Messages, testMessages) {
+		t.Errorf("request = %+v", *req)
+	}
+}
+
+func TestOpenAIGenerateStreamErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+		full   string
+	}{
+		{"ends before done", http.StatusOK, sse(`{"choices":[{"delta":{"content":"The "}}]}`), "unexpected EOF", "The "},
+		{"empty body", http.StatusOK, "", "unexpected EOF", ""},
+		{"bad event", http.StatusOK, sse(`{"choices":`), "openai chat stream", ""},
+		{"error event", http.StatusOK, sse(`{"error":{"message":"overloaded"}}`), "overloaded", ""},
+		{"status", http.StatusTooManyRequests, `{"error":{"message":"rate limited"}}`, "status 429: {\"error\"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, _ := stubOpenAI(t, tt.status, tt.body)
+			full, err := c.GenerateStream(context.Background(), testMessages, func(string) error { return nil })
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+			if full != tt.full {
+				t.Errorf("full = %q, want %q", full, tt.full)
+			}
+		})
+	}
+
+	c, _, _ := stubOpenAI(t, http.StatusOK, sse(`{"choices":[{"delta":{"content":"x"}}]}`))
+	_, err := c.GenerateStream(context.Background(), testMessages, func(string) error { return nil })
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("err = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestOpenAIGenerate(t *testing.T) {
+	c, req, header := stubOpenAI(t, http.StatusOK, `{"choices":[
+		{"index":0,"message":{"role":"assistant","content":"The answer."},"finish_reason":"stop"},
+		{"index":1,"message":{"role":"assistant","content":"Another."},"finish_reason":"stop"}]}`)
+	c.Temperature = 0.7
+
+	messages := []Message{
+		{Role: RoleSystem, Content: "Be brief."},
+		{Role: RoleUser, Content: "Question?"},
+		{Role: RoleAssistant, Content: "Earlier reply."},
+		{Role: RoleUser, Content: "More?"},
+	}
+	got, err := c.Generate(context.Background(), messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "The answer." {
+		t.Errorf("Generate = %q", got)
+	}
+	if req.Model != "test-model" || req.Stream || req.Temperature != 0.7 {
+		t.Errorf("request = %+v", *req)
+	}
+	if !reflect.DeepEqual(req.Messages, messages) {
+		t.Errorf("messages = %+v, want roles and order kept: %+v", req.Messages, messages)
+	}
+	if auth := header.Get("Authorization"); auth != "Bearer secret" {
+		t.Errorf("Authorization = %q", auth)
+	}
+}
+
+func TestOpenAIGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"error body", http.StatusUnauthorized, `{"error":{"message":"invalid api key"}}`, "status 401: invalid api key"},
+		{"error body with 200", http.StatusOK, `{"error":{"message":"context too long"}}`, "context too long"},
+		{"non-json status", http.StatusBadGateway, `<html>bad gateway</html>`, "status 502: <html>bad gateway</html>"},
+		{"non-200 without error", http.StatusServiceUnavailable, `{"choices":[]}`, "status 503"},
+		{"no choices", http.StatusOK, `{"choices":[]}`, "no choices"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, _ := stubOpenAI(t, tt.status, tt.body)
+			_, err := c.Generate(context.Background(), testMessages)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIWithoutKey(t *testing.T) {
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer srv.Close()
+
+	c := NewOpenAI(srv.URL, "", "local-model")
+	if got, err := c.Generate(context.Background(), testMessages); err != nil || got != "ok" {
+		t.Fatalf("Generate = %q, %v", got, err)
+	}
+	if auth, ok := header["Authorization"]; ok {
+		t.Errorf("Authorization sent without a key: %q", auth)
+	}
+}
@@ -0,0 +1,207 @@
+package rag
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/brunomgama/go_rag/internal/common"
+	"github.com/brunomgama/go_rag/internal/store"
+)
+
+// hit is a retrieved chunk, or a run of neighbouring chunks merged around
+// one, as read from the point payloads.
+type hit struct {
+	doc, chunk, section  string
+	title, chapter       string
+	parentID, parentText string
+	text, snippet        string
+	page, index          int
+	score                float32
+	span                 *Span
+	chunks               int // chunks merged into text
+	first, last          chunkPos
+}
+
+type chunkPos struct{ page, index int }
+
+func (p chunkPos) before(o chunkPos) bool {
+	return p.page < o.page || p.page == o.page && p.index < o.index
+}
+
+func readHit(payload map[string]any, score float32) hit {
+	h := hit{
+		page:   common.AsInt(payload["page"]),
+		index:  common.AsInt(payload["index"]),
+		score:  score,
+		span:   payloadSpan(payload),
+		chunks: 1,
+	}
+	h.text, _ = payload["text"].(string)
+	h.doc, _ = payload["doc_id"].(string)
+	h.chunk, _ = payload["chunk_id"].(string)
+	h.section, _ = payload["section"].(string)
+	h.title, _ = payload["title"].(string)
+	h.chapter, _ = payload["chapter"].(string)
+	h.parentID, _ = payload["parent_id"].(string)
+	h.parentText, _ = payload["parent_text"].(string)
+	h.snippet = h.text
+	h.first = chunkPos{h.page, h.index}
+	h.last = h.first
+	return h
+}
+
+// withNeighbors widens every hit to the Neighbors chunks on either side of
+// it in its document and merges hits whose windows touch, so contiguous
+// text reaches the prompt once and in order. Chunks of PDFs are ordered by
+// page and index, looking one page back and ahead so a hit at the end of a
+// page picks up the start of the next. Hits that carry a parent passage
+// already have their context and are left alone.
+func (s *Service) withNeighbors(ctx context.Context, hits []hit) ([]hit, error) {
+	var out []hit
+	for _, h := range hits {
+		if h.parentID != "" && h.parentText != "" {
+			out = append(out, h)
+			continue
+		}
+
+		window, err := s.window(ctx, h)
+		if !common.IsNilValue(err) {
+			return nil, err
+		}
+
+		merged := false
+		for i := range out {
+			if out[i].doc == h.doc && out[i].parentID == "" && touches(out[i], window) {
+				out[i] = mergeHits(out[i], window)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			out = append(out, window)
+		}
+	}
+	return out, nil
+}
+
+// window fetches the neighbours of h and returns them merged into h.
+func (s *Service) window(ctx context.Context, h hit) (hit, error) {
+	n := s.Neighbors
+	// page 0 holds every chunk of unpaged documents, so only there is the
+	// index range narrowed down
+	firstPage, lastPage := float64(max(0, h.page-1)), float64(h.page+1)
+	filter := store.MatchValue("doc_id", h.doc)
+	if h.page == 0 {
+		lo, hi := float64(h.index-n), float64(h.index+n)
+		firstPage, lastPage = 0, 0
+		filter.Must = append(filter.Must, store.Condition{Key: "index", Range: &store.Range{Gte: &lo, Lte: &hi}})
+	}
+	filter.Must = append(filter.Must, store.Condition{Key: "page", Range: &store.Range{Gte: &firstPage, Lte: &lastPage}})
+
+	records, err := store.ScrollAll(ctx, s.Store, &filter)
+	if !common.IsNilValue(err) {
+		return hit{}, err
+	}
+
+	chunks := make([]hit, 0, len(records))
+	for _, r := range records {
+		chunks = append(chunks, readHit(r.Payload, 0))
+	}
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].first.before(chunks[j].first) })
+
+	at := sort.Search(len(chunks), func(i int) bool { return !chunks[i].first.before(h.first) })
+	if at == len(chunks) || chunks[at].first != h.first {
+		return h, nil // the hit itself is gone, e.g. deleted since the search
+	}
+
+	out := h
+	for i := at - 1; i >= max(0, at-n); i-- {
+		out = mergeHits(chunks[i], out)
+	}
+	for i := at + 1; i <= min(len(chunks)-1, at+n); i++ {
+		out = mergeHits(out, chunks[i])
+	}
+	return out, nil
+}
+
+// touches reports whether the chunk runs of a and b overlap or are next to
+// each other within one page.
+func touches(a, b hit) bool {
+	if b.first.before(a.first) {
+		a, b = b, a
+	}
+	if !a.last.before(b.first) {
+		return true
+	}
+	return a.last.page == b.first.page && a.last.index+1 == b.first.index
+}
+
+// mergeHits joins two runs of one document into one, keeping the identity
+// and score of whichever scored higher.
+func mergeHits(a, b hit) hit {
+	best := a
+	if b.score > a.score {
+		best = b
+	}
+	if b.first.before(a.first) {
+		a, b = b, a
+	}
+
+	out := best
+	out.first = a.first
+	out.last = b.last
+	if a.last.before(b.last) {
+		out.text = joinOverlap(a.text, b.text)
+		out.chunks = a.chunks + b.chunks
+	} else {
+		out.text, out.chunks = a.text, a.chunks // b lies within a
+		out.last = a.last
+	}
+
+	out.span = nil
+	if a.span != nil && b.span != nil {
+		out.span = &Span{Start: min(a.span.Start, b.span.Start), End: max(a.span.End, b.span.End)}
+		if a.first.page == out.last.page && a.span.PageStart != nil && b.span.PageStart != nil {
+			start, end := min(*a.span.PageStart, *b.span.PageStart), max(*a.span.PageEnd, *b.span.PageEnd)
+			out.span.PageStart, out.span.PageEnd = &start, &end
+		}
+	}
+	return out
+}
+
+// joinOverlap appends b to a, dropping the words at the start of b that
+// repeat the end of a because of chunk overlap.
+func joinOverlap(a, b string) string {
+	aw, bw := strings.Fields(a), strings.Fields(b)
+	for k := min(len(aw), len(bw)); k > 0; k-- {
+		if equalWords(aw[len(aw)-k:], bw[:k]) {
+			return a + " " + dropWords(b, k)
+		}
+	}
+	return a + " " + b
+}
+
+func equalWords(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// dropWords returns s without its first n words, keeping the layout of the
+// rest.
+func dropWords(s string, n int) string {
+	s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	for ; n > 0 && s != ""; n-- {
+		end := strings.IndexFunc(s, unicode.IsSpace)
+		if end < 0 {
+			return ""
+		}
+		s = strings.TrimLeftFunc(s[end:], unicode.IsSpace)
+	}
+	return s
+}
@@ -0,0 +1,46 @@
+package rag
+
+import (
+	"context"
+
+	"github.com/brunomgama/go_rag/internal/common"
+	"github.com/brunomgama/go_rag/internal/store"
+)
+
+// withParents fills in the parent passage of hits that only refer to their
+// parent by id. Ingest stores each parent's text once, on its first child,
+// so it is looked up among the chunks of the same document and parent.
+func (s *Service) withParents(ctx context.Context, hits []hit) error {
+	texts := make(map[string]string)
+	for _, h := range hits {
+		if h.parentID != "" && h.parentText != "" {
+			texts[h.doc+"::"+h.parentID] = h.parentText
+		}
+	}
+
+	for i := range hits {
+		h := &hits[i]
+		if h.parentID == "" || h.parentText != "" {
+			continue
+		}
+		key := h.doc + "::" + h.parentID
+		text, ok := texts[key]
+		if !ok {
+			filter := store.MatchValue("doc_id", h.doc)
+			filter.Must = append(filter.Must, store.Condition{Key: "parent_id", Match: &store.Match{Value: h.parentID}})
+			records, err := store.ScrollAll(ctx, s.Store, &filter)
+			if !common.IsNilValue(err) {
+				return err
+			}
+			for _, r := range records {
+				if t, _ := r.Payload["parent_text"].(string); t != "" {
+					text = t
+					break
+				}
+			}
+			texts[key] = text
+		}
+		h.parentText = text
+	}
+	return nil
+}
@@ -12,20 +12,49 @@ import (
 	"github.com/brunomgama/go_rag/internal/store"
 )
 
+const systemPrompt = `You are a precise assistant. Answer the user USING ONLY the Sources below.
+If the answer is not in the sources, say "I don't know".
+Cite like [Source 1], [Source 2] referencing the source blocks.`
+
+// Source blocks are clamped to keep the prompt bounded. Parent passages get
+// more room since they exist to carry context around the matched chunk.
+const (
+	maxSourceChars = 900
+	maxParentChars = 3600
+)
+
 type Service struct {
-	Embed    *embed.Client
-	LLM      *llm.Client
-	Store    *store.Qdrant
+	Embed    embed.Embedder
+	LLM      llm.Generator
+	Store    store.VectorStore
 	TopK     int
 	MinScore *float32
+	// Neighbors is how many chunks before and after each hit, within the
+	// same document, are added to its source block. Zero disables it.
+	Neighbors int
 }
 
 type Citation struct {
-	DocID   string  `json:"doc_id"`
-	Page    int     `json:"page"`
-	ChunkID string  `json:"chunk_id"`
-	Score   float32 `json:"score"`
-	Snippet string  `json:"snippet"`
+	DocID    string  `json:"doc_id"`
+	Page     int     `json:"page"`
+	ChunkID  string  `json:"chunk_id"`
+	Section  string  `json:"section,omitempty"`
+	Chapter  string  `json:"chapter,omitempty"`
+	Title    string  `json:"title,omitempty"`
+	Score    float32 `json:"score"`
+	Snippet  string  `json:"snippet"`
+	Span     *Span   `json:"span,omitempty"`
+	ParentID string  `json:"parent_id,omitempty"`
+}
+
+// Span is where a citation's chunk sits in the parsed document, in
+// characters with End exclusive. PageStart and PageEnd are relative to the
+// page's text and only set for paged formats such as PDF.
+type Span struct {
+	Start     int  `json:"start"`
+	End       int  `json:"end"`
+	PageStart *int `json:"page_start,omitempty"`
+	PageEnd   *int `json:"page_end,omitempty"`
 }
 
 type Answer struct {
@@ -50,13 +79,66 @@ func (s *Service) embedQuery(ctx context.Context, question string) ([]float32, e
 
 func (s *Service) Query(ctx context.Context, question string, topK int) (Answer, error) {
 	start := time.Now()
+
+	messages, citations, err := s.buildPrompt(ctx, question, topK)
+	if !common.IsNilValue(err) {
+		return Answer{}, err
+	}
+
+	out, err := s.LLM.Generate(ctx, messages)
+
+	if !common.IsNilValue(err) {
+		return Answer{}, err
+	}
+
+	return Answer{
+		Answer:    strings.TrimSpace(out),
+		Citations: citations,
+		LatencyMS: time.Since(start).Milliseconds(),
+	}, nil
+}
+
+// QueryStream runs the same pipeline as Query but hands every generated token
+// to onToken as it arrives. The returned Answer carries the full text and the
+// citations once generation has finished. Generators that cannot stream
+// deliver the whole answer as a single token.
+func (s *Service) QueryStream(ctx context.Context, question string, topK int, onToken func(string) error) (Answer, error) {
+	start := time.Now()
+
+	messages, citations, err := s.buildPrompt(ctx, question, topK)
+	if !common.IsNilValue(err) {
+		return Answer{}, err
+	}
+
+	var out string
+	if streamer, ok := s.LLM.(llm.Streamer); ok {
+		out, err = streamer.GenerateStream(ctx, messages, onToken)
+	} else {
+		out, err = s.LLM.Generate(ctx, messages)
+		if common.IsNilValue(err) {
+			err = onToken(out)
+		}
+	}
+
+	if !common.IsNilValue(err) {
+		return Answer{}, err
+	}
+
+	return Answer{
+		Answer:    strings.TrimSpace(out),
+		Citations: citations,
+		LatencyMS: time.Since(start).Milliseconds(),
+	}, nil
+}
+
+func (s *Service) buildPrompt(ctx context.Context, question string, topK int) ([]llm.Message, []Citation, error) {
 	if topK <= 0 {
 		topK = s.TopK
 	}
 
 	vec, err := s.embedQuery(ctx, question)
 	if !common.IsNilValue(err) {
-		return Answer{}, nil
+		return nil, nil, err
 	}
 
 	req := store.SearchRequest{
@@ -73,33 +155,53 @@ func (s *Service) Query(ctx context.Context, question string, topK int) (Answer,
 	results, err := s.Store.Search(ctx, req)
 
 	if !common.IsNilValue(err) {
-		return Answer{}, nil
+		return nil, nil, err
 	}
 
-	var src strings.Builder
-	citations := make([]Citation, 0, len(results))
-
-	for i, r := range results {
+	hits := make([]hit, 0, len(results))
+	for _, r := range results {
 		if common.IsNilValue(r.Payload) {
 			continue
 		}
+		hits = append(hits, readHit(r.Payload, r.Score))
+	}
+	if err := s.withParents(ctx, hits); err != nil {
+		return nil, nil, err
+	}
+	if s.Neighbors > 0 {
+		hits, err = s.withNeighbors(ctx, hits)
+		if !common.IsNilValue(err) {
+			return nil, nil, err
+		}
+	}
 
-		text, _ := r.Payload["text"].(string)
-		doc, _ := r.Payload["doc_id"].(string)
-		page := common.AsInt(r.Payload["page"])
-		chunk, _ := r.Payload["chunk_id"].(string)
+	var src strings.Builder
+	citations := make([]Citation, 0, len(hits))
+	parents := make(map[string]bool)
+
+	for _, h := range hits {
+		// children of one parent collapse into a single block showing the
+		// parent, cited by its best scoring child
+		body := common.Clamp(h.text, maxSourceChars*h.chunks)
+		parentID := ""
+		if h.parentID != "" && h.parentText != "" {
+			key := h.doc + "::" + h.parentID
+			if parents[key] {
+				continue
+			}
+			parents[key] = true
+			parentID = h.parentID
+			body = common.Clamp(h.parentText, maxParentChars)
+		}
 
-		fmt.Fprintf(&src, "\n[Source %d] (%s p.%d, %s)\n%s\n", i+1, doc, page, chunk, common.Clamp(text, 900))
+		fmt.Fprintf(&src, "\n[Source %d] (%s)\n%s\n", len(citations)+1, sourceHeader(h.doc, h.page, h.chunk, h.section), body)
 		citations = append(citations, Citation{
-			DocID: doc, Page: page, ChunkID: chunk, Score: r.Score, Snippet: common.Snippet(text, 280),
+			DocID: h.doc, Page: h.page, ChunkID: h.chunk, Section: h.section, ParentID: parentID, Score: h.score, Snippet: common.Snippet(h.snippet, 280),
+			Chapter: h.chapter, Title: h.title, Span: h.span,
 		})
 	}
 
 	prompt := strings.TrimSpace(fmt.Sprintf(`
-You are a precise assistant. Answer the user USING ONLY the Sources below.
-If the answer is not in the sources, say "I don't know".
-Cite like [Source 1], [Source 2] referencing the source blocks.
-
 Question:
 %s
 
@@ -109,15 +211,30 @@ Sources:
 Answer with citations:
 `, question, src.String()))
 
-	out, err := s.LLM.Generate(ctx, prompt)
+	return []llm.Message{
+		{Role: llm.RoleSystem, Content: systemPrompt},
+		{Role: llm.RoleUser, Content: prompt},
+	}, citations, nil
+}
 
-	if !common.IsNilValue(err) {
-		return Answer{}, err
+func sourceHeader(doc string, page int, chunk, section string) string {
+	header := fmt.Sprintf("%s p.%d, %s", doc, page, chunk)
+	if section != "" {
+		header += ", " + section
+	}
+	return header
+}
+
+// payloadSpan reads the chunk offsets stored at ingest time, if any.
+func payloadSpan(payload map[string]any) *Span {
+	if _, ok := payload["start"]; !ok {
+		return nil
 	}
 
-	return Answer{
-		Answer:    strings.TrimSpace(out),
-		Citations: citations,
-		LatencyMS: time.Since(start).Milliseconds(),
-	}, nil
+	span := &Span{Start: common.AsInt(payload["start"]), End: common.AsInt(payload["end"])}
+	if _, ok := payload["page_start"]; ok {
+		start, end := common.AsInt(payload["page_start"]), common.AsInt(payload["page_end"])
+		span.PageStart, span.PageEnd = &start, &end
+	}
+	return span
 }
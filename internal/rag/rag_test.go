@@ -0,0 +1,201 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/brunomgama/go_rag/internal/llm"
+	"github.com/brunomgama/go_rag/internal/store"
+)
+
+// topics gives every text a vector counting the topic words it contains, so
+// similarity follows shared topics.
+var topics = []string{"dice", "money", "jail", "trade"}
+
+type fakeEmbedder struct{}
+
+func (fakeEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec := make([]float32, len(topics)+1)
+		vec[len(topics)] = 0.1 // keep texts without topics off the zero vector
+		for j, topic := range topics {
+			vec[j] = float32(strings.Count(strings.ToLower(text), topic))
+		}
+		out[i] = vec
+	}
+	return out, nil
+}
+
+type fakeGenerator struct {
+	messages []llm.Message
+}
+
+func (g *fakeGenerator) Generate(ctx context.Context, messages []llm.Message) (string, error) {
+	g.messages = messages
+	return "  the answer [Source 1]\n", nil
+}
+
+// chunk is a point payload as written by ingest.
+type chunk struct {
+	doc   string
+	page  int
+	index int
+	text  string
+	extra map[string]any
+}
+
+func newTestService(t *testing.T, chunks ...chunk) (*Service, *fakeGenerator) {
+	t.Helper()
+	ctx := context.Background()
+	st, err := store.NewMemory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	points := make([]store.Point, len(chunks))
+	for i, c := range chunks {
+		vecs, _ := fakeEmbedder{}.Embed(ctx, []string{c.text})
+		chunkID := fmt.Sprintf("p%d-c%d", c.page, c.index)
+		payload := map[string]any{
+			"doc_id":   c.doc,
+			"page":     c.page,
+			"index":    c.index,
+			"chunk_id": chunkID,
+			"text":     c.text,
+		}
+		for k, v := range c.extra {
+			payload[k] = v
+		}
+		points[i] = store.Point{ID: store.PointID(c.doc, chunkID), Vector: vecs[0], Payload: payload}
+	}
+	if err := st.Upsert(ctx, points); err != nil {
+		t.Fatal(err)
+	}
+
+	gen := &fakeGenerator{}
+	return &Service{Embed: fakeEmbedder{}, LLM: gen, Store: st, TopK: 3}, gen
+}
+
+func TestQuery(t *testing.T) {
+	svc, gen := newTestService(t,
+		chunk{doc: "rules.pdf", page: 1, index: 0, text: "Roll the dice to move."},
+		chunk{doc: "rules.pdf", page: 2, index: 0, text: "Collect money when passing Go."},
+		chunk{doc: "rules.pdf", page: 3, index: 0, text: "Go to jail on three doubles of the dice."},
+	)
+
+	ans, err := svc.Query(context.Background(), "What do the dice do?", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ans.Answer != "the answer [Source 1]" {
+		t.Errorf("answer = %q", ans.Answer)
+	}
+	if len(ans.Citations) != 2 {
+		t.Fatalf("got %d citations, want 2", len(ans.Citations))
+	}
+	if c := ans.Citations[0]; c.DocID != "rules.pdf" || c.Page != 1 || c.ChunkID != "p1-c0" {
+		t.Errorf("first citation = %+v", c)
+	}
+	if ans.Citations[0].Score < ans.Citations[1].Score {
+		t.Errorf("citations not ordered by score: %+v", ans.Citations)
+	}
+
+	prompt := gen.messages[len(gen.messages)-1].Content
+	for _, want := range []string{"What do the dice do?", "[Source 1] (rules.pdf p.1, p1-c0)", "Roll the dice to move.", "[Source 2]"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt lacks %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "Collect money") {
+		t.Errorf("prompt holds a chunk beyond topK:\n%s", prompt)
+	}
+}
+
+func TestQueryMinScore(t *testing.T) {
+	svc, _ := newTestService(t,
+		chunk{doc: "a.md", index: 0, text: "dice"},
+		chunk{doc: "a.md", index: 1, text: "money"},
+	)
+	threshold := float32(0.5)
+	svc.MinScore = &threshold
+
+	ans, err := svc.Query(context.Background(), "dice", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ans.Citations) != 1 || ans.Citations[0].ChunkID != "p0-c0" {
+		t.Errorf("citations = %+v", ans.Citations)
+	}
+}
+
+func TestQueryNeighbors(t *testing.T) {
+	svc, gen := newTestService(t,
+		chunk{doc: "a.md", index: 0, text: "Setup comes first."},
+		chunk{doc: "a.md", index: 1, text: "Then trade with others."},
+		chunk{doc: "a.md", index: 2, text: "Finally count the points."},
+		chunk{doc: "a.md", index: 3, text: "Unrelated closing words."},
+		chunk{doc: "b.md", index: 0, text: "Other document text."},
+	)
+	svc.TopK = 1
+	svc.Neighbors = 1
+
+	ans, err := svc.Query(context.Background(), "trade", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ans.Citations) != 1 || ans.Citations[0].ChunkID != "p0-c1" {
+		t.Fatalf("citations = %+v", ans.Citations)
+	}
+	prompt := gen.messages[len(gen.messages)-1].Content
+	if !strings.Contains(prompt, "Setup comes first. Then trade with others. Finally count the points.") {
+		t.Errorf("neighbours not merged in order:\n%s", prompt)
+	}
+	if strings.Contains(prompt, "Unrelated") || strings.Contains(prompt, "Other document") {
+		t.Errorf("prompt holds chunks outside the window:\n%s", prompt)
+	}
+}
+
+func TestQueryStreamWithoutStreamer(t *testing.T) {
+	svc, _ := newTestService(t, chunk{doc: "a.md", text: "dice"})
+
+	var tokens []string
+	ans, err := svc.QueryStream(context.Background(), "dice", 0, func(tok string) error {
+		tokens = append(tokens, tok)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || strings.TrimSpace(tokens[0]) != ans.Answer {
+		t.Errorf("tokens = %q, answer = %q", tokens, ans.Answer)
+	}
+}
+
+func TestQueryFetchesParentByID(t *testing.T) {
+	parent := "Roll the dice to move. Then trade with others. Pay money for rent."
+	svc, gen := newTestService(t,
+		chunk{doc: "a.md", index: 0, text: "Roll the dice to move.", extra: map[string]any{"parent_id": "parent#0", "parent_text": parent}},
+		chunk{doc: "a.md", index: 1, text: "Then trade with others.", extra: map[string]any{"parent_id": "parent#0"}},
+		chunk{doc: "a.md", index: 2, text: "Pay money for rent.", extra: map[string]any{"parent_id": "parent#0"}},
+		chunk{doc: "b.md", index: 0, text: "Trade elsewhere.", extra: map[string]any{"parent_id": "parent#0"}},
+	)
+	svc.TopK = 2
+
+	ans, err := svc.Query(context.Background(), "trade", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ans.Citations) != 2 {
+		t.Fatalf("citations = %+v", ans.Citations)
+	}
+	prompt := gen.messages[len(gen.messages)-1].Content
+	if strings.Count(prompt, parent) != 1 {
+		t.Errorf("want the parent of a.md once in the prompt:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, "Trade elsewhere.") {
+		t.Errorf("b.md hit without a stored parent is missing:\n%s", prompt)
+	}
+}
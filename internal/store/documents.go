@@ -0,0 +1,161 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"github.com/brunomgama/go_rag/internal/common"
+)
+
+type DocumentInfo struct {
+	DocID  string `json:"doc_id"`
+	Chunks int    `json:"chunks"`
+	Pages  int    `json:"pages"`
+}
+
+// ChunkInfo is a chunk as listed by DocumentChunks. DocID tells apart the
+// members of an archive, whose chunk ids repeat.
+type ChunkInfo struct {
+	DocID   string `json:"doc_id"`
+	ChunkID string `json:"chunk_id"`
+	Page    int    `json:"page"`
+	Index   int    `json:"index"`
+	Snippet string `json:"snippet"`
+}
+
+// ScrollAll pages through every point matching filter.
+func ScrollAll(ctx context.Context, vs VectorStore, filter *Filter) ([]Record, error) {
+	var out []Record
+	req := ScrollRequest{Filter: filter, Limit: 256, WithPayload: true}
+
+	for {
+		page, err := vs.Scroll(ctx, req)
+		if !common.IsNilValue(err) {
+			return nil, err
+		}
+		out = append(out, page.Points...)
+		if page.NextOffset == nil || len(page.Points) == 0 {
+			return out, nil
+		}
+		req.Offset = page.NextOffset
+	}
+}
+
+func ListDocuments(ctx context.Context, vs VectorStore) ([]DocumentInfo, error) {
+	records, err := ScrollAll(ctx, vs, nil)
+	if !common.IsNilValue(err) {
+		return nil, err
+	}
+
+	byDoc := make(map[string]*DocumentInfo)
+	pages := make(map[string]map[int]bool)
+	for _, r := range records {
+		doc, _ := r.Payload["doc_id"].(string)
+		if doc == "" {
+			continue
+		}
+		info, ok := byDoc[doc]
+		if !ok {
+			info = &DocumentInfo{DocID: doc}
+			byDoc[doc] = info
+			pages[doc] = make(map[int]bool)
+		}
+		info.Chunks++
+		if page := common.AsInt(r.Payload["page"]); page > 0 {
+			pages[doc][page] = true
+		}
+	}
+
+	out := make([]DocumentInfo, 0, len(byDoc))
+	for doc, info := range byDoc {
+		info.Pages = len(pages[doc])
+		out = append(out, *info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].DocID < out[j].DocID })
+	return out, nil
+}
+
+// documentFilter matches the chunks of docID, or of every member when docID
+// names an ingested archive.
+func documentFilter(docID string) Filter {
+	return Filter{Should: []Condition{
+		{Key: "doc_id", Match: &Match{Value: docID}},
+		{Key: "bundle", Match: &Match{Value: docID}},
+	}}
+}
+
+func DocumentChunks(ctx context.Context, vs VectorStore, docID string) ([]ChunkInfo, error) {
+	filter := documentFilter(docID)
+	records, err := ScrollAll(ctx, vs, &filter)
+	if !common.IsNilValue(err) {
+		return nil, err
+	}
+
+	out := make([]ChunkInfo, 0, len(records))
+	for _, r := range records {
+		chunk, _ := r.Payload["chunk_id"].(string)
+		text, _ := r.Payload["text"].(string)
+		doc, _ := r.Payload["doc_id"].(string)
+		out = append(out, ChunkInfo{
+			DocID:   doc,
+			ChunkID: chunk,
+			Page:    common.AsInt(r.Payload["page"]),
+			Index:   common.AsInt(r.Payload["index"]),
+			Snippet: common.Snippet(text, 160),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].DocID != out[j].DocID {
+			return out[i].DocID < out[j].DocID
+		}
+		if out[i].Page != out[j].Page {
+			return out[i].Page < out[j].Page
+		}
+		return out[i].Index < out[j].Index
+	})
+	return out, nil
+}
+
+// HasDocument reports whether any chunk of docID is stored.
+func HasDocument(ctx context.Context, vs VectorStore, docID string) (bool, error) {
+	filter := documentFilter(docID)
+	page, err := vs.Scroll(ctx, ScrollRequest{Filter: &filter, Limit: 1})
+	if !common.IsNilValue(err) {
+		return false, err
+	}
+	return len(page.Points) > 0, nil
+}
+
+func DeleteDocument(ctx context.Context, vs VectorStore, docID string) error {
+	return vs.Delete(ctx, documentFilter(docID))
+}
+
+// DeleteStale removes the chunks of docID whose point keys are not in keep:
+// what an earlier revision of the document left behind once the new one is
+// upserted. It returns the number of chunks removed.
+func DeleteStale(ctx context.Context, vs VectorStore, docID string, keep []string) (int, error) {
+	filter := documentFilter(docID)
+	records, err := ScrollAll(ctx, vs, &filter)
+	if !common.IsNilValue(err) {
+		return 0, err
+	}
+
+	kept := make(map[string]bool, len(keep))
+	for _, k := range keep {
+		kept[k] = true
+	}
+	var stale []any
+	for _, r := range records {
+		if key, _ := r.Payload["point_key"].(string); !kept[key] {
+			stale = append(stale, key)
+		}
+	}
+
+	for i := 0; i < len(stale); i += 256 {
+		batch := stale[i:min(i+256, len(stale))]
+		if err := vs.Delete(ctx, Filter{Must: []Condition{{Key: "point_key", Match: &Match{Any: batch}}}}); err != nil {
+			return 0, err
+		}
+	}
+	return len(stale), nil
+}
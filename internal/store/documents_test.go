@@ -0,0 +1,85 @@
+package store
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestDeleteStale(t *testing.T) {
+	point := func(doc, chunk string, extra ...string) Point {
+		payload := map[string]any{"doc_id": doc, "chunk_id": chunk, "point_key": PointKey(doc, chunk)}
+		if len(extra) > 0 {
+			payload["bundle"] = extra[0]
+		}
+		return Point{ID: PointID(doc, chunk), Vector: []float32{1, 0}, Payload: payload}
+	}
+	m := newTestMemory(t,
+		point("a.md", "doc#0"), point("a.md", "doc#1"), point("a.md", "doc#2"),
+		point("b.md", "doc#0"),
+		point("z.zip!/x.md", "doc#0", "z.zip"), point("z.zip!/y.md", "doc#0", "z.zip"),
+	)
+	ctx := context.Background()
+
+	n, err := DeleteStale(ctx, m, "a.md", []string{PointKey("a.md", "doc#0")})
+	if err != nil || n != 2 {
+		t.Fatalf("DeleteStale = %d, %v; want 2", n, err)
+	}
+	n, err = DeleteStale(ctx, m, "z.zip", []string{PointKey("z.zip!/y.md", "doc#0")})
+	if err != nil || n != 1 {
+		t.Fatalf("DeleteStale of bundle = %d, %v; want 1", n, err)
+	}
+
+	records, err := ScrollAll(ctx, m, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, r := range records {
+		keys = append(keys, r.Payload["point_key"].(string))
+	}
+	sort.Strings(keys)
+	want := []string{"a.md::doc#0", "b.md::doc#0", "z.zip!/y.md::doc#0"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("left %v, want %v", keys, want)
+	}
+}
+
+func TestDocumentChunksOfBundle(t *testing.T) {
+	point := func(doc, chunk string, index int, bundle string) Point {
+		payload := map[string]any{"doc_id": doc, "chunk_id": chunk, "index": index, "text": doc + " " + chunk}
+		if bundle != "" {
+			payload["bundle"] = bundle
+		}
+		return Point{ID: PointID(doc, chunk), Vector: []float32{1, 0}, Payload: payload}
+	}
+	m := newTestMemory(t,
+		point("z.zip!/y.md", "doc#1", 1, "z.zip"), point("z.zip!/x.md", "doc#1", 1, "z.zip"),
+		point("z.zip!/y.md", "doc#0", 0, "z.zip"), point("z.zip!/x.md", "doc#0", 0, "z.zip"),
+		point("a.md", "doc#0", 0, ""),
+	)
+
+	chunks, err := DocumentChunks(context.Background(), m, "z.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range chunks {
+		got = append(got, c.DocID+" "+c.ChunkID)
+	}
+	want := []string{"z.zip!/x.md doc#0", "z.zip!/x.md doc#1", "z.zip!/y.md doc#0", "z.zip!/y.md doc#1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("chunks = %q, want %q", got, want)
+	}
+}
+
+func TestHasDocument(t *testing.T) {
+	m := newTestMemory(t, Point{ID: PointID("z.zip!/x.md", "doc#0"), Vector: []float32{1, 0},
+		Payload: map[string]any{"doc_id": "z.zip!/x.md", "bundle": "z.zip"}})
+	for doc, want := range map[string]bool{"z.zip!/x.md": true, "z.zip": true, "x.md": false} {
+		if got, err := HasDocument(context.Background(), m, doc); err != nil || got != want {
+			t.Errorf("HasDocument(%q) = %v, %v; want %v", doc, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package store
+
+import (
+	"crypto/sha1"
+	"fmt"
+)
+
+// pointNamespace is the UUIDv5 namespace for chunk point IDs. Changing it
+// changes every ID, so existing collections would need a full re-ingest.
+var pointNamespace = [16]byte{
+	0x6b, 0xa7, 0xb8, 0x10, 0x9d, 0xad, 0x11, 0xd1,
+	0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8,
+}
+
+// PointKey is the human readable identity of a chunk, kept in the payload
+// because Qdrant only accepts unsigned integers or UUIDs as point IDs.
+func PointKey(docID, chunkID string) string {
+	return docID + "::" + chunkID
+}
+
+// PointID returns the name-based (version 5) UUID for a chunk, so re-ingesting
+// the same chunk overwrites its previous point.
+func PointID(docID, chunkID string) string {
+	h := sha1.New()
+	h.Write(pointNamespace[:])
+	h.Write([]byte(PointKey(docID, chunkID)))
+	sum := h.Sum(nil)
+
+	var u [16]byte
+	copy(u[:], sum[:16])
+	u[6] = (u[6] & 0x0f) | 0x50
+	u[8] = (u[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package store
+
+// lockFile is a no-op where flock is unavailable: there the memory store
+// file must belong to a single process.
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,392 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brunomgama/go_rag/internal/common"
+)
+
+// Memory is a brute-force cosine VectorStore kept in process memory. When a
+// path is given the points are loaded from and written back to a JSON file.
+// Every change re-reads the file under an exclusive lock before writing it,
+// and reads pick up a file changed by someone else, which is enough to share
+// a small corpus between cmd/ingest and cmd/api without running Qdrant.
+type Memory struct {
+	mu     sync.RWMutex
+	path   string
+	dim    int
+	points map[string]Point
+	stamp  fileStamp // of the file as last loaded or saved
+}
+
+type memorySnapshot struct {
+	Dim    int     `json:"dim"`
+	Points []Point `json:"points"`
+}
+
+type fileStamp struct {
+	mod  time.Time
+	size int64
+}
+
+func NewMemory(path string) (*Memory, error) {
+	m := &Memory{path: path, points: make(map[string]Point)}
+	if path == "" {
+		return m, nil
+	}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Memory) EnsureCollection(ctx context.Context, dim int) error {
+	return m.update(func() error {
+		if m.dim != 0 && m.dim != dim {
+			return fmt.Errorf("memory store: collection has dimension %d, got %d", m.dim, dim)
+		}
+		m.dim = dim
+		return nil
+	})
+}
+
+func (m *Memory) Upsert(ctx context.Context, points []Point) error {
+	return m.update(func() error {
+		for _, p := range points {
+			if m.dim != 0 && len(p.Vector) != m.dim {
+				return fmt.Errorf("memory store: point %s has dimension %d, want %d", p.ID, len(p.Vector), m.dim)
+			}
+		}
+		for _, p := range points {
+			m.points[p.ID] = p
+		}
+		return nil
+	})
+}
+
+func (m *Memory) Search(ctx context.Context, req SearchRequest) ([]SearchResult, error) {
+	if err := m.refresh(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make([]SearchResult, 0, len(m.points))
+	for _, p := range m.points {
+		if req.Filter != nil && !req.Filter.matches(p.Payload) {
+			continue
+		}
+
+		score := common.Cosine(req.Vector, p.Vector)
+		if req.ScoreThreshold != nil && score < *req.ScoreThreshold {
+			continue
+		}
+
+		r := SearchResult{ID: p.ID, Score: score}
+		if req.WithPayload {
+			r.Payload = p.Payload
+		}
+		out = append(out, r)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].ID.(string) < out[j].ID.(string)
+	})
+	if req.TopK > 0 && len(out) > req.TopK {
+		out = out[:req.TopK]
+	}
+	return out, nil
+}
+
+func (m *Memory) Delete(ctx context.Context, filter Filter) error {
+	return m.update(func() error {
+		for id, p := range m.points {
+			if filter.matches(p.Payload) {
+				delete(m.points, id)
+			}
+		}
+		return nil
+	})
+}
+
+func (m *Memory) Scroll(ctx context.Context, req ScrollRequest) (ScrollPage, error) {
+	if err := m.refresh(); err != nil {
+		return ScrollPage{}, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+
+	ids := make([]string, 0, len(m.points))
+	for id, p := range m.points {
+		if req.Filter != nil && !req.Filter.matches(p.Payload) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	if req.Offset != nil {
+		from := fmt.Sprint(req.Offset)
+		ids = ids[sort.SearchStrings(ids, from):]
+	}
+
+	var page ScrollPage
+	for i, id := range ids {
+		if i == limit {
+			page.NextOffset = id
+			break
+		}
+		p := m.points[id]
+		rec := Record{ID: id}
+		if req.WithPayload {
+			rec.Payload = p.Payload
+		}
+		if req.WithVector {
+			rec.Vector = p.Vector
+		}
+		page.Points = append(page.Points, rec)
+	}
+	return page, nil
+}
+
+// update applies change to the latest snapshot and writes the result back.
+// The file lock is held from reading the snapshot to renaming the new one
+// into place, so processes sharing the file do not drop each other's
+// changes.
+func (m *Memory) update(change func() error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.path == "" {
+		return change()
+	}
+	if dir := filepath.Dir(m.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	unlock, err := lockFile(m.path + ".lock")
+	if !common.IsNilValue(err) {
+		return fmt.Errorf("memory store %s: lock: %w", m.path, err)
+	}
+	defer unlock()
+
+	if err := m.load(); err != nil {
+		return err
+	}
+	if err := change(); err != nil {
+		m.stamp = fileStamp{} // reload on next access, dropping the partial change
+		return err
+	}
+	if err := m.save(); err != nil {
+		m.stamp = fileStamp{}
+		return err
+	}
+	return nil
+}
+
+// refresh reloads the snapshot when another process changed the file.
+func (m *Memory) refresh() error {
+	if m.path == "" {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.load()
+}
+
+// load replaces the points with the file's, unless the file is unchanged
+// since it was last loaded or saved. A missing file keeps what is in memory.
+func (m *Memory) load() error {
+	info, err := os.Stat(m.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if !common.IsNilValue(err) {
+		return err
+	}
+	stamp := fileStamp{mod: info.ModTime(), size: info.Size()}
+	if stamp == m.stamp {
+		return nil
+	}
+
+	b, err := os.ReadFile(m.path)
+	if !common.IsNilValue(err) {
+		return err
+	}
+	var snap memorySnapshot
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return fmt.Errorf("memory store %s: %w", m.path, err)
+	}
+	m.dim = snap.Dim
+	m.points = make(map[string]Point, len(snap.Points))
+	for _, p := range snap.Points {
+		m.points[p.ID] = p
+	}
+	m.stamp = stamp
+	return nil
+}
+
+func (m *Memory) save() error {
+	snap := memorySnapshot{Dim: m.dim, Points: make([]Point, 0, len(m.points))}
+	for _, p := range m.points {
+		snap.Points = append(snap.Points, p)
+	}
+	sort.Slice(snap.Points, func(i, j int) bool { return snap.Points[i].ID < snap.Points[j].ID })
+
+	b, err := json.Marshal(snap)
+	if !common.IsNilValue(err) {
+		return err
+	}
+
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		return err
+	}
+	info, err := os.Stat(m.path)
+	if !common.IsNilValue(err) {
+		return err
+	}
+	m.stamp = fileStamp{mod: info.ModTime(), size: info.Size()}
+	return nil
+}
+
+func (f Filter) matches(payload map[string]any) bool {
+	for _, c := range f.Must {
+		if !c.matches(payload) {
+			return false
+		}
+	}
+	for _, c := range f.MustNot {
+		if c.matches(payload) {
+			return false
+		}
+	}
+	if len(f.Should) == 0 {
+		return true
+	}
+	for _, c := range f.Should {
+		if c.matches(payload) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Condition) matches(payload map[string]any) bool {
+	v, ok := lookup(payload, c.Key)
+	if !ok {
+		return false
+	}
+
+	if c.Match != nil {
+		if c.Match.Any != nil {
+			found := false
+			for _, want := range c.Match.Any {
+				if valueEqual(v, want) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		} else if !valueEqual(v, c.Match.Value) {
+			return false
+		}
+	}
+
+	if c.Range != nil {
+		x, ok := asFloat(v)
+		if !ok {
+			return false
+		}
+		r := c.Range
+		if (r.Gt != nil && !(x > *r.Gt)) || (r.Gte != nil && !(x >= *r.Gte)) ||
+			(r.Lt != nil && !(x < *r.Lt)) || (r.Lte != nil && !(x <= *r.Lte)) {
+			return false
+		}
+	}
+	return true
+}
+
+func lookup(payload map[string]any, key string) (any, bool) {
+	var cur any = payload
+	for _, part := range strings.Split(key, ".") {
+		var ok bool
+		switch m := cur.(type) {
+		case map[string]any:
+			cur, ok = m[part]
+		case map[string]string:
+			cur, ok = m[part]
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func valueEqual(a, b any) bool {
+	if x, ok := asFloat(a); ok {
+		y, ok := asFloat(b)
+		return ok && x == y
+	}
+	if list, ok := a.([]any); ok {
+		for _, item := range list {
+			if valueEqual(item, b) {
+				return true
+			}
+		}
+		return false
+	}
+	if list, ok := a.([]string); ok {
+		for _, item := range list {
+			if valueEqual(item, b) {
+				return true
+			}
+		}
+		return false
+	}
+	switch a.(type) {
+	case string, bool:
+		return a == b
+	default:
+		return false
+	}
+}
+
+func asFloat(v any) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case float32:
+		return float64(t), true
+	case int:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,256 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestFilterMatches(t *testing.T) {
+	payload := map[string]any{
+		"doc_id": "a.pdf",
+		"page":   float64(3),
+		"tags":   []any{"rules", "setup"},
+		"meta":   map[string]string{"author": "Ann"},
+		"fields": map[string]any{"price": 12.5},
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty", Filter{}, true},
+		{"match", MatchValue("doc_id", "a.pdf"), true},
+		{"match other", MatchValue("doc_id", "b.pdf"), false},
+		{"match number", MatchValue("page", 3), true},
+		{"missing key", MatchValue("section", "x"), false},
+		{"list element", MatchValue("tags", "setup"), true},
+		{"nested string map", MatchValue("meta.author", "Ann"), true},
+		{"nested any map", Filter{Must: []Condition{{Key: "fields.price", Range: &Range{Gt: ptr(12.0)}}}}, true},
+		{"any", Filter{Must: []Condition{{Key: "doc_id", Match: &Match{Any: []any{"b.pdf", "a.pdf"}}}}}, true},
+		{"any none", Filter{Must: []Condition{{Key: "doc_id", Match: &Match{Any: []any{"b.pdf"}}}}}, false},
+		{"range inside", Filter{Must: []Condition{{Key: "page", Range: &Range{Gte: ptr(3.0), Lte: ptr(3.0)}}}}, true},
+		{"range outside", Filter{Must: []Condition{{Key: "page", Range: &Range{Lt: ptr(3.0)}}}}, false},
+		{"range on string", Filter{Must: []Condition{{Key: "doc_id", Range: &Range{Gt: ptr(0.0)}}}}, false},
+		{"should one", Filter{Should: []Condition{
+			{Key: "doc_id", Match: &Match{Value: "b.pdf"}},
+			{Key: "page", Match: &Match{Value: 3}},
+		}}, true},
+		{"should none", Filter{Should: []Condition{{Key: "doc_id", Match: &Match{Value: "b.pdf"}}}}, false},
+		{"must not", Filter{MustNot: []Condition{{Key: "doc_id", Match: &Match{Value: "a.pdf"}}}}, false},
+		{"must and must not", Filter{
+			Must:    []Condition{{Key: "doc_id", Match: &Match{Value: "a.pdf"}}},
+			MustNot: []Condition{{Key: "page", Match: &Match{Value: 4}}},
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.matches(payload); got != tt.want {
+				t.Errorf("matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newTestMemory(t *testing.T, points ...Point) *Memory {
+	t.Helper()
+	m, err := NewMemory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.EnsureCollection(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Upsert(context.Background(), points); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func resultIDs(results []SearchResult) []any {
+	ids := make([]any, len(results))
+	for i, r := range results {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+func TestMemorySearch(t *testing.T) {
+	m := newTestMemory(t,
+		Point{ID: "far", Vector: []float32{0, 1}, Payload: map[string]any{"doc_id": "a"}},
+		Point{ID: "near", Vector: []float32{1, 0.1}, Payload: map[string]any{"doc_id": "b"}},
+		Point{ID: "exact-b", Vector: []float32{1, 0}, Payload: map[string]any{"doc_id": "a"}},
+		Point{ID: "exact-a", Vector: []float32{2, 0}, Payload: map[string]any{"doc_id": "b"}},
+	)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		req  SearchRequest
+		want []any
+	}{
+		{"ordered by score then id", SearchRequest{Vector: []float32{1, 0}}, []any{"exact-a", "exact-b", "near", "far"}},
+		{"top k", SearchRequest{Vector: []float32{1, 0}, TopK: 2}, []any{"exact-a", "exact-b"}},
+		{"threshold", SearchRequest{Vector: []float32{1, 0}, ScoreThreshold: ptr(float32(0.5))}, []any{"exact-a", "exact-b", "near"}},
+		{"filter", SearchRequest{Vector: []float32{1, 0}, Filter: ptr(MatchValue("doc_id", "a"))}, []any{"exact-b", "far"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Search(ctx, tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ids := resultIDs(got); !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("ids = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	got, _ := m.Search(ctx, SearchRequest{Vector: []float32{1, 0}, TopK: 1})
+	if got[0].Payload != nil {
+		t.Errorf("payload returned without WithPayload")
+	}
+	got, _ = m.Search(ctx, SearchRequest{Vector: []float32{1, 0}, TopK: 1, WithPayload: true})
+	if got[0].Payload["doc_id"] != "b" {
+		t.Errorf("payload = %v", got[0].Payload)
+	}
+}
+
+func TestMemoryScroll(t *testing.T) {
+	var points []Point
+	for _, id := range []string{"e", "a", "d", "b", "c"} {
+		points = append(points, Point{ID: id, Vector: []float32{1, 0}, Payload: map[string]any{"odd": id == "a" || id == "c" || id == "e"}})
+	}
+	m := newTestMemory(t, points...)
+	ctx := context.Background()
+
+	var (
+		pages  [][]string
+		offset any
+	)
+	for {
+		page, err := m.Scroll(ctx, ScrollRequest{Limit: 2, Offset: offset})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, r := range page.Points {
+			ids = append(ids, r.ID.(string))
+			if r.Payload != nil || r.Vector != nil {
+				t.Errorf("record %v carries payload or vector", r.ID)
+			}
+		}
+		pages = append(pages, ids)
+		if page.NextOffset == nil {
+			break
+		}
+		offset = page.NextOffset
+	}
+	want := [][]string{{"a", "b"}, {"c", "d"}, {"e"}}
+	if !reflect.DeepEqual(pages, want) {
+		t.Errorf("pages = %v, want %v", pages, want)
+	}
+
+	page, err := m.Scroll(ctx, ScrollRequest{Filter: ptr(MatchValue("odd", true)), WithPayload: true, WithVector: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Points) != 3 || page.NextOffset != nil {
+		t.Fatalf("filtered scroll = %+v", page)
+	}
+	if page.Points[0].Payload == nil || page.Points[0].Vector == nil {
+		t.Errorf("payload or vector missing: %+v", page.Points[0])
+	}
+}
+
+func TestMemoryDimension(t *testing.T) {
+	m := newTestMemory(t)
+	ctx := context.Background()
+	if err := m.Upsert(ctx, []Point{{ID: "x", Vector: []float32{1, 2, 3}}}); err == nil {
+		t.Error("upsert of a 3-d point into a 2-d store succeeded")
+	}
+	if err := m.EnsureCollection(ctx, 3); err == nil {
+		t.Error("changing the dimension succeeded")
+	}
+}
+
+func TestMemoryDelete(t *testing.T) {
+	m := newTestMemory(t,
+		Point{ID: "1", Vector: []float32{1, 0}, Payload: map[string]any{"doc_id": "a"}},
+		Point{ID: "2", Vector: []float32{1, 0}, Payload: map[string]any{"doc_id": "b"}},
+	)
+	if err := m.Delete(context.Background(), MatchValue("doc_id", "a")); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := m.Search(context.Background(), SearchRequest{Vector: []float32{1, 0}})
+	if ids := resultIDs(got); !reflect.DeepEqual(ids, []any{"2"}) {
+		t.Errorf("ids = %v", ids)
+	}
+}
+
+// Two stores sharing a file stand in for cmd/ingest and cmd/api.
+func TestMemorySharedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "points.json")
+	ctx := context.Background()
+
+	a, err := NewMemory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewMemory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.EnsureCollection(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Upsert(ctx, []Point{{ID: "wrong", Vector: []float32{1, 2, 3}}}); err == nil {
+		t.Error("b accepted a 3-d point after a set the dimension to 2")
+	}
+	if err := a.Upsert(ctx, []Point{{ID: "from-a", Vector: []float32{1, 0}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Upsert(ctx, []Point{{ID: "from-b", Vector: []float32{0, 1}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, m := range map[string]*Memory{"a": a, "b": b} {
+		got, err := m.Search(ctx, SearchRequest{Vector: []float32{1, 1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 2 {
+			t.Errorf("store %s sees %v, want both points", name, resultIDs(got))
+		}
+	}
+
+	if err := a.Delete(ctx, Filter{Must: []Condition{{Key: "doc_id", Match: &Match{Value: "none"}}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Upsert(ctx, []Point{{ID: "from-b", Vector: []float32{0, 2}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewMemory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := reopened.Scroll(ctx, ScrollRequest{Limit: 10, WithVector: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Points) != 2 {
+		t.Fatalf("file holds %d points, want 2", len(page.Points))
+	}
+	if reopened.dim != 2 {
+		t.Errorf("dim = %d, want 2", reopened.dim)
+	}
+	if v := page.Points[1].Vector; v[1] != 2 {
+		t.Errorf("from-b vector = %v, want the second upsert", v)
+	}
+}
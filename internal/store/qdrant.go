@@ -3,18 +3,19 @@ package store
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	"github.com/brunomgama/go_rag/internal/common"
 	"github.com/go-resty/resty/v2"
 )
 
 type SearchRequest struct {
-	Vector         []float32      `json:"vector"`
-	TopK           int            `json:"top"`
-	WithPayload    bool           `json:"with_payload"`
-	WithVector     bool           `json:"with_vector"`
-	ScoreThreshold *float32       `json:"score_threshold,omitempty"`
-	Filter         map[string]any `json:"filter,omitempty"`
+	Vector         []float32 `json:"vector"`
+	TopK           int       `json:"top"`
+	WithPayload    bool      `json:"with_payload"`
+	WithVector     bool      `json:"with_vector"`
+	ScoreThreshold *float32  `json:"score_threshold,omitempty"`
+	Filter         *Filter   `json:"filter,omitempty"`
 }
 
 type SearchResult struct {
@@ -47,6 +48,19 @@ func NewQdrant(baseUrl, collection string) *Qdrant {
 }
 
 func (q *Qdrant) EnsureCollection(ctx context.Context, dim int) error {
+	path := fmt.Sprintf("/collections/%s", q.Collection)
+
+	res, err := q.http.R().SetContext(ctx).SetError(&qdrantError{}).Get(path)
+	if !common.IsNilValue(err) {
+		return err
+	}
+	if res.StatusCode() == http.StatusOK {
+		return nil
+	}
+	if res.StatusCode() != http.StatusNotFound {
+		return statusError("get collection", res)
+	}
+
 	body := map[string]any{
 		"vectors": map[string]any{
 			"size":     dim,
@@ -54,15 +68,12 @@ func (q *Qdrant) EnsureCollection(ctx context.Context, dim int) error {
 		},
 	}
 
-	var res map[string]any
-	path := fmt.Sprintf("/collections/%s", q.Collection)
-
-	_, err := q.http.R().
+	res, err = q.http.R().
 		SetContext(ctx).
 		SetBody(body).
-		SetResult(&res).
+		SetError(&qdrantError{}).
 		Put(path)
-	return err
+	return checkResponse("create collection", res, err)
 }
 
 func (q *Qdrant) Upsert(ctx context.Context, points []Point) error {
@@ -70,26 +81,81 @@ func (q *Qdrant) Upsert(ctx context.Context, points []Point) error {
 		"points": points,
 	}
 
-	var res map[string]any
-	path := fmt.Sprintf("/collections/%s/points", q.Collection)
+	path := fmt.Sprintf("/collections/%s/points?wait=true", q.Collection)
 
-	_, err := q.http.R().
+	res, err := q.http.R().
 		SetContext(ctx).
 		SetBody(body).
-		SetResult(&res).
+		SetError(&qdrantError{}).
 		Put(path)
-	return err
+	return checkResponse("upsert", res, err)
 }
 
 func (q *Qdrant) Search(ctx context.Context, req SearchRequest) ([]SearchResult, error) {
 	var out searchResp
 
-	path := fmt.Sprintf("/collection/%s/points/search", q.Collection)
-	_, err := q.http.R().SetContext(ctx).SetBody(req).SetResult(&out).Post(path)
+	path := fmt.Sprintf("/collections/%s/points/search", q.Collection)
+	res, err := q.http.R().SetContext(ctx).SetBody(req).SetResult(&out).SetError(&qdrantError{}).Post(path)
 
-	if !common.IsNilValue(err) {
+	if err := checkResponse("search", res, err); err != nil {
 		return nil, err
 	}
 
 	return out.Result, nil
 }
+
+func (q *Qdrant) Delete(ctx context.Context, filter Filter) error {
+	body := map[string]any{
+		"filter": filter,
+	}
+
+	path := fmt.Sprintf("/collections/%s/points/delete?wait=true", q.Collection)
+
+	res, err := q.http.R().
+		SetContext(ctx).
+		SetBody(body).
+		SetError(&qdrantError{}).
+		Post(path)
+	return checkResponse("delete", res, err)
+}
+
+func (q *Qdrant) Scroll(ctx context.Context, req ScrollRequest) (ScrollPage, error) {
+	var out struct {
+		Result ScrollPage `json:"result"`
+		Status string     `json:"status"`
+	}
+
+	path := fmt.Sprintf("/collections/%s/points/scroll", q.Collection)
+	res, err := q.http.R().SetContext(ctx).SetBody(req).SetResult(&out).SetError(&qdrantError{}).Post(path)
+
+	if err := checkResponse("scroll", res, err); err != nil {
+		return ScrollPage{}, err
+	}
+
+	return out.Result, nil
+}
+
+// qdrantError is the body Qdrant sends with non-2xx responses, where status
+// is an object instead of the usual "ok" string.
+type qdrantError struct {
+	Status struct {
+		Error string `json:"error"`
+	} `json:"status"`
+}
+
+func checkResponse(op string, res *resty.Response, err error) error {
+	if !common.IsNilValue(err) {
+		return fmt.Errorf("qdrant %s: %w", op, err)
+	}
+	if res.IsError() {
+		return statusError(op, res)
+	}
+	return nil
+}
+
+func statusError(op string, res *resty.Response) error {
+	if e, ok := res.Error().(*qdrantError); ok && e.Status.Error != "" {
+		return fmt.Errorf("qdrant %s status %d: %s", op, res.StatusCode(), e.Status.Error)
+	}
+	return fmt.Errorf("qdrant %s status %d: %s", op, res.StatusCode(), common.Snippet(res.String(), 300))
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brunomgama/go_rag/internal/config"
+)
+
+type VectorStore interface {
+	EnsureCollection(ctx context.Context, dim int) error
+	Upsert(ctx context.Context, points []Point) error
+	Search(ctx context.Context, req SearchRequest) ([]SearchResult, error)
+	Delete(ctx context.Context, filter Filter) error
+	Scroll(ctx context.Context, req ScrollRequest) (ScrollPage, error)
+}
+
+type Filter struct {
+	Must    []Condition `json:"must,omitempty"`
+	Should  []Condition `json:"should,omitempty"`
+	MustNot []Condition `json:"must_not,omitempty"`
+}
+
+type Condition struct {
+	Key   string `json:"key"`
+	Match *Match `json:"match,omitempty"`
+	Range *Range `json:"range,omitempty"`
+}
+
+type Match struct {
+	Value any   `json:"value,omitempty"`
+	Any   []any `json:"any,omitempty"`
+}
+
+type Range struct {
+	Gt  *float64 `json:"gt,omitempty"`
+	Gte *float64 `json:"gte,omitempty"`
+	Lt  *float64 `json:"lt,omitempty"`
+	Lte *float64 `json:"lte,omitempty"`
+}
+
+type ScrollRequest struct {
+	Filter      *Filter `json:"filter,omitempty"`
+	Limit       int     `json:"limit,omitempty"`
+	Offset      any     `json:"offset,omitempty"`
+	WithPayload bool    `json:"with_payload"`
+	WithVector  bool    `json:"with_vector"`
+}
+
+type Record struct {
+	ID      any            `json:"id"`
+	Payload map[string]any `json:"payload"`
+	Vector  []float32      `json:"vector,omitempty"`
+}
+
+type ScrollPage struct {
+	Points     []Record `json:"points"`
+	NextOffset any      `json:"next_page_offset"`
+}
+
+func MatchValue(key string, value any) Filter {
+	return Filter{Must: []Condition{{Key: key, Match: &Match{Value: value}}}}
+}
+
+func FromConfig(cfg config.Config) (VectorStore, error) {
+	switch cfg.VectorStore {
+	case "", "qdrant":
+		return NewQdrant(cfg.QdrantURL, cfg.QdrantCollection), nil
+	case "memory":
+		return NewMemory(cfg.MemoryStorePath)
+	default:
+		return nil, fmt.Errorf("unknown vector store %q", cfg.VectorStore)
+	}
+}
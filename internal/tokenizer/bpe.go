@@ -0,0 +1,104 @@
+package tokenizer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/brunomgama/go_rag/internal/common"
+)
+
+// pretokenize approximates the split applied by GPT style tokenizers before
+// merging: contractions, letter runs, up to three digits and punctuation
+// runs, each with an optional leading space, and whitespace runs.
+var pretokenize = regexp.MustCompile(`(?i:'s|'t|'re|'ve|'m|'ll|'d)| ?\p{L}+| ?\p{N}{1,3}| ?[^\s\p{L}\p{N}]+|\s+`)
+
+// BPE is a byte level byte-pair encoder over a ranked vocabulary, the format
+// used by tiktoken files such as cl100k_base.tiktoken: one base64 encoded
+// token and its rank per line. Lower ranks are merged first.
+type BPE struct {
+	name  string
+	ranks map[string]int
+}
+
+func LoadBPE(path string) (*BPE, error) {
+	f, err := os.Open(path)
+	if !common.IsNilValue(err) {
+		return nil, err
+	}
+	defer f.Close()
+
+	ranks := make(map[string]int)
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("tokenizer %s:%d: want \"<base64 token> <rank>\"", path, line)
+		}
+		tok, err := base64.StdEncoding.DecodeString(fields[0])
+		if !common.IsNilValue(err) {
+			return nil, fmt.Errorf("tokenizer %s:%d: %w", path, line, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if !common.IsNilValue(err) {
+			return nil, fmt.Errorf("tokenizer %s:%d: %w", path, line, err)
+		}
+		ranks[string(tok)] = rank
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("tokenizer %s: %w", path, err)
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("tokenizer %s: empty vocabulary", path)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return &BPE{name: "bpe:" + name, ranks: ranks}, nil
+}
+
+func (b *BPE) Name() string { return b.name }
+
+func (b *BPE) Count(text string) int {
+	n := 0
+	for _, piece := range pretokenize.FindAllString(text, -1) {
+		n += b.countPiece(piece)
+	}
+	return n
+}
+
+// countPiece merges the bytes of piece pairwise, always picking the adjacent
+// pair with the lowest rank, until no known pair is left.
+func (b *BPE) countPiece(piece string) int {
+	if _, ok := b.ranks[piece]; ok {
+		return 1
+	}
+
+	// bounds[i] is the byte offset where part i starts
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	for len(bounds) > 2 {
+		best, at := -1, -1
+		for i := 0; i+2 < len(bounds); i++ {
+			rank, ok := b.ranks[piece[bounds[i]:bounds[i+2]]]
+			if ok && (best < 0 || rank < best) {
+				best, at = rank, i
+			}
+		}
+		if at < 0 {
+			break
+		}
+		bounds = append(bounds[:at+1], bounds[at+2:]...)
+	}
+	return len(bounds) - 1
+}
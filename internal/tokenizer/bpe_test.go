@@ -0,0 +1,117 @@
+package tokenizer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brunomgama/go_rag/internal/config"
+)
+
+// testdata/tiny.tiktoken ranks "bc" 0, "ab" 1, "cd" 2, "hello" 3,
+// " world" 4 and " " 5.
+func loadTiny(t *testing.T) *BPE {
+	t.Helper()
+	b, err := LoadBPE(filepath.Join("testdata", "tiny.tiktoken"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestLoadBPE(t *testing.T) {
+	b := loadTiny(t)
+	if b.Name() != "bpe:tiny" {
+		t.Errorf("name = %q", b.Name())
+	}
+	if len(b.ranks) != 6 || b.ranks["bc"] != 0 || b.ranks[" world"] != 4 {
+		t.Errorf("ranks = %v", b.ranks)
+	}
+}
+
+func TestLoadBPEErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"one field", "YWI=\n", "tiny.tiktoken:1: want"},
+		{"three fields", "YWI= 1\nYmM= 0 x\n", "tiny.tiktoken:2: want"},
+		{"bad base64", "YWI= 1\n!!! 2\n", "tiny.tiktoken:2: illegal base64"},
+		{"bad rank", "YWI= one\n", "tiny.tiktoken:1: strconv.Atoi"},
+		{"empty", "\n\n", "empty vocabulary"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tiny.tiktoken")
+			os.WriteFile(path, []byte(tt.body), 0o644)
+			_, err := LoadBPE(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := LoadBPE(filepath.Join(t.TempDir(), "missing.tiktoken")); !os.IsNotExist(err) {
+		t.Errorf("missing file: err = %v", err)
+	}
+}
+
+func TestBPECountPiece(t *testing.T) {
+	b := loadTiny(t)
+	tests := []struct {
+		piece string
+		want  int
+	}{
+		// "bc" has the lowest rank, so it merges before "ab" and "cd" can;
+		// merging left to right or by "cd" first would leave 2 tokens
+		{"abcd", 3},
+		{"ab", 1},
+		{"abab", 2},
+		{"cdcd", 2},
+		{"hello", 1},
+		{" world", 1},
+		{"xyz", 3},
+		{"é", 2}, // two bytes, no merge
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := b.countPiece(tt.piece); got != tt.want {
+			t.Errorf("countPiece(%q) = %d, want %d", tt.piece, got, tt.want)
+		}
+	}
+}
+
+func TestBPECount(t *testing.T) {
+	b := loadTiny(t)
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"hello world", 2},
+		{"hello  world", 8}, // "hello", "  " as two bytes, "world" as five
+		{"12345", 5},        // "123" and "45", one byte each
+		{"ab, cd!", 5},      // "ab", ",", " " and "cd", "!"
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := b.Count(tt.text); got != tt.want {
+			t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	tok, err := FromConfig(config.Config{})
+	if err != nil || tok.Name() != "words" || tok.Count(" two  words ") != 2 {
+		t.Errorf("FromConfig without a path = %v, %v", tok, err)
+	}
+	tok, err = FromConfig(config.Config{TokenizerPath: filepath.Join("testdata", "tiny.tiktoken")})
+	if err != nil || tok.Name() != "bpe:tiny" {
+		t.Errorf("FromConfig = %v, %v", tok, err)
+	}
+	if _, err := FromConfig(config.Config{TokenizerPath: "testdata/missing"}); err == nil {
+		t.Error("no error for a missing vocabulary")
+	}
+}
@@ -0,0 +1,35 @@
+package tokenizer
+
+import (
+	"strings"
+
+	"github.com/brunomgama/go_rag/internal/common"
+	"github.com/brunomgama/go_rag/internal/config"
+)
+
+// Tokenizer counts tokens the way an embedding model would see them.
+type Tokenizer interface {
+	Name() string
+	Count(text string) int
+}
+
+// FromConfig loads the BPE vocabulary at TOKENIZER_PATH, or falls back to
+// counting whitespace separated words when none is configured.
+func FromConfig(cfg config.Config) (Tokenizer, error) {
+	if cfg.TokenizerPath == "" {
+		return Words{}, nil
+	}
+	bpe, err := LoadBPE(cfg.TokenizerPath)
+	if !common.IsNilValue(err) {
+		return nil, err
+	}
+	return bpe, nil
+}
+
+// Words treats every whitespace separated word as one token. It undercounts
+// for real models, so targets should leave some headroom when it is used.
+type Words struct{}
+
+func (Words) Name() string { return "words" }
+
+func (Words) Count(text string) int { return len(strings.Fields(text)) }